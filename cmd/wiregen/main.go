@@ -0,0 +1,29 @@
+// Command wiregen generates internal/container/wire_gen.go, which constructs
+// the container's default components without reflection. It is run by
+// go generate in internal/container with the wiregen build tag.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"log"
+	"os"
+
+	"go-spring.com/internal/container"
+)
+
+func main() {
+	output := flag.String("o", "wire_gen.go", "output file")
+	flag.Parse()
+
+	log.SetFlags(0)
+	log.SetPrefix("wiregen: ")
+
+	var buf bytes.Buffer
+	if err := container.GenerateWiring(&buf); err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(*output, buf.Bytes(), 0644); err != nil {
+		log.Fatalf("failed to write %s: %v", *output, err)
+	}
+}
@@ -16,7 +16,8 @@ type RedisCache struct {
 	metrics *observability.CacheMetrics
 }
 
-// NewRedisCache creates a new Redis cache instance
+// NewRedisCache creates a new Redis cache instance. The connection is made
+// lazily; use Ping to check that Redis is reachable.
 func NewRedisCache(host string, port int, password string, db int) (*RedisCache, error) {
 	client := redis.NewClient(&redis.Options{
 		Addr:     fmt.Sprintf("%s:%d", host, port),
@@ -24,17 +25,20 @@ func NewRedisCache(host string, port int, password string, db int) (*RedisCache,
 		DB:       db,
 	})
 
-	// Test connection
-	if err := client.Ping(context.Background()).Err(); err != nil {
-		return nil, fmt.Errorf("failed to connect to Redis: %v", err)
-	}
-
 	return &RedisCache{
 		client:  client,
 		metrics: observability.NewCacheMetrics(),
 	}, nil
 }
 
+// Ping checks the connection to Redis
+func (c *RedisCache) Ping(ctx context.Context) error {
+	if err := c.client.Ping(ctx).Err(); err != nil {
+		return fmt.Errorf("failed to connect to Redis: %w", err)
+	}
+	return nil
+}
+
 // Get retrieves a value from Redis
 func (c *RedisCache) Get(ctx context.Context, key string) (interface{}, bool) {
 	val, err := c.client.Get(ctx, key).Result()
@@ -76,3 +80,8 @@ func (c *RedisCache) Delete(ctx context.Context, key string) error {
 func (c *RedisCache) Clear(ctx context.Context) error {
 	return c.client.FlushDB(ctx).Err()
 }
+
+// Close closes the Redis client
+func (c *RedisCache) Close() error {
+	return c.client.Close()
+}
@@ -0,0 +1,66 @@
+package cache
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+)
+
+// ReloadableCache delegates to a cache that can be replaced at runtime, so
+// callers holding it keep working when the underlying cache is reconnected
+type ReloadableCache struct {
+	mu      sync.RWMutex
+	current Cache
+}
+
+// NewReloadableCache creates a reloadable cache delegating to c
+func NewReloadableCache(c Cache) *ReloadableCache {
+	return &ReloadableCache{
+		current: c,
+	}
+}
+
+// Swap replaces the underlying cache and returns the previous one
+func (c *ReloadableCache) Swap(next Cache) Cache {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	prev := c.current
+	c.current = next
+	return prev
+}
+
+// Current returns the underlying cache
+func (c *ReloadableCache) Current() Cache {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.current
+}
+
+// Get retrieves a value from the underlying cache
+func (c *ReloadableCache) Get(ctx context.Context, key string) (interface{}, bool) {
+	return c.Current().Get(ctx, key)
+}
+
+// Set stores a value in the underlying cache
+func (c *ReloadableCache) Set(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+	return c.Current().Set(ctx, key, value, ttl)
+}
+
+// Delete removes a value from the underlying cache
+func (c *ReloadableCache) Delete(ctx context.Context, key string) error {
+	return c.Current().Delete(ctx, key)
+}
+
+// Clear removes all values from the underlying cache
+func (c *ReloadableCache) Clear(ctx context.Context) error {
+	return c.Current().Clear(ctx)
+}
+
+// Close closes the underlying cache if it holds a connection
+func (c *ReloadableCache) Close() error {
+	if closer, ok := c.Current().(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
@@ -0,0 +1,334 @@
+package config
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FieldError describes a problem with a single configuration key
+type FieldError struct {
+	Key     string
+	Message string
+}
+
+func (e FieldError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Key, e.Message)
+}
+
+// BindError lists every field that could not be bound
+type BindError struct {
+	Errors []FieldError
+}
+
+func (e *BindError) Error() string {
+	return formatFieldErrors("invalid configuration", e.Errors)
+}
+
+func formatFieldErrors(prefix string, errs []FieldError) string {
+	msgs := make([]string, len(errs))
+	for i, err := range errs {
+		msgs[i] = err.Error()
+	}
+	return fmt.Sprintf("%s (%d errors): %s", prefix, len(errs), strings.Join(msgs, "; "))
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// Bind maps nested configuration data onto target, which must be a pointer
+// to a struct. Fields are matched by their `config` tag, which may be a
+// dotted path relative to the enclosing struct; untagged fields use their
+// lower-cased name and `config:"-"` skips a field. Missing keys fall back to
+// the `default` tag. Values are coerced to the field type and all failures
+// are reported together in a *BindError.
+func Bind(data map[string]interface{}, target interface{}) error {
+	v := reflect.ValueOf(target)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("bind target must be a pointer to a struct, got %T", target)
+	}
+
+	var errs []FieldError
+	bindStruct(data, v.Elem(), "", &errs)
+	if len(errs) > 0 {
+		return &BindError{Errors: errs}
+	}
+	return nil
+}
+
+// Keys returns the dotted keys of every leaf field of target
+func Keys(target interface{}) []string {
+	var keys []string
+	walkFields(reflect.TypeOf(target), "", func(key string, _ reflect.StructField) {
+		keys = append(keys, key)
+	})
+	return keys
+}
+
+// Defaults returns the values of all `default` tags on target as nested data
+func Defaults(target interface{}) map[string]interface{} {
+	data := make(map[string]interface{})
+	walkFields(reflect.TypeOf(target), "", func(key string, field reflect.StructField) {
+		if def, ok := field.Tag.Lookup("default"); ok {
+			setPath(data, key, def)
+		}
+	})
+	return data
+}
+
+// Lookup returns the value stored under a dotted key in nested data
+func Lookup(data map[string]interface{}, key string) (interface{}, bool) {
+	current := data
+	parts := strings.Split(key, ".")
+	for i, part := range parts {
+		value, ok := current[part]
+		if !ok {
+			return nil, false
+		}
+		if i == len(parts)-1 {
+			return value, true
+		}
+		if current, ok = value.(map[string]interface{}); !ok {
+			return nil, false
+		}
+	}
+	return nil, false
+}
+
+func walkFields(t reflect.Type, prefix string, fn func(key string, field reflect.StructField)) {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		key, ok := fieldKey(field)
+		if !ok {
+			continue
+		}
+		path := joinKey(prefix, key)
+		if isSection(field.Type) {
+			walkFields(field.Type, path, fn)
+			continue
+		}
+		fn(path, field)
+	}
+}
+
+func bindStruct(data map[string]interface{}, v reflect.Value, prefix string, errs *[]FieldError) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		key, ok := fieldKey(field)
+		if !ok {
+			continue
+		}
+		path := joinKey(prefix, key)
+		raw, found := Lookup(data, key)
+
+		if isSection(field.Type) {
+			section, isMap := raw.(map[string]interface{})
+			if found && raw != nil && !isMap {
+				*errs = append(*errs, FieldError{Key: path, Message: fmt.Sprintf("expected object, got %T", raw)})
+				continue
+			}
+			bindStruct(section, v.Field(i), path, errs)
+			continue
+		}
+
+		if !found || raw == nil {
+			def, ok := field.Tag.Lookup("default")
+			if !ok {
+				continue
+			}
+			raw = def
+		}
+		if err := setValue(v.Field(i), raw); err != nil {
+			*errs = append(*errs, FieldError{Key: path, Message: err.Error()})
+		}
+	}
+}
+
+func fieldKey(field reflect.StructField) (string, bool) {
+	if !field.IsExported() {
+		return "", false
+	}
+	tag := field.Tag.Get("config")
+	if tag == "-" {
+		return "", false
+	}
+	if tag == "" {
+		tag = strings.ToLower(field.Name)
+	}
+	return tag, true
+}
+
+func isSection(t reflect.Type) bool {
+	return t.Kind() == reflect.Struct && t != reflect.TypeOf(time.Time{})
+}
+
+func joinKey(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
+
+func setValue(v reflect.Value, raw interface{}) error {
+	if v.Type() == durationType {
+		d, err := toDuration(raw)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		switch r := raw.(type) {
+		case string:
+			v.SetString(r)
+		case bool, int, int64, float64:
+			v.SetString(fmt.Sprintf("%v", r))
+		default:
+			return fmt.Errorf("expected string, got %T", raw)
+		}
+	case reflect.Bool:
+		switch r := raw.(type) {
+		case bool:
+			v.SetBool(r)
+		case string:
+			b, err := strconv.ParseBool(r)
+			if err != nil {
+				return fmt.Errorf("expected boolean, got %q", r)
+			}
+			v.SetBool(b)
+		default:
+			return fmt.Errorf("expected boolean, got %T", raw)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := toInt(raw)
+		if err != nil {
+			return err
+		}
+		if v.OverflowInt(n) {
+			return fmt.Errorf("value %d out of range", n)
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := toInt(raw)
+		if err != nil {
+			return err
+		}
+		if n < 0 || v.OverflowUint(uint64(n)) {
+			return fmt.Errorf("value %d out of range", n)
+		}
+		v.SetUint(uint64(n))
+	case reflect.Float32, reflect.Float64:
+		f, err := toFloat(raw)
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported field type %s", v.Type())
+		}
+		items, err := toStrings(raw)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(items).Convert(v.Type()))
+	default:
+		return fmt.Errorf("unsupported field type %s", v.Type())
+	}
+	return nil
+}
+
+func toInt(raw interface{}) (int64, error) {
+	switch r := raw.(type) {
+	case int:
+		return int64(r), nil
+	case int64:
+		return r, nil
+	case float64:
+		if r != math.Trunc(r) {
+			return 0, fmt.Errorf("expected integer, got %v", r)
+		}
+		return int64(r), nil
+	case string:
+		n, err := strconv.ParseInt(strings.TrimSpace(r), 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("expected integer, got %q", r)
+		}
+		return n, nil
+	default:
+		return 0, fmt.Errorf("expected integer, got %T", raw)
+	}
+}
+
+func toFloat(raw interface{}) (float64, error) {
+	switch r := raw.(type) {
+	case int:
+		return float64(r), nil
+	case int64:
+		return float64(r), nil
+	case float64:
+		return r, nil
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(r), 64)
+		if err != nil {
+			return 0, fmt.Errorf("expected number, got %q", r)
+		}
+		return f, nil
+	default:
+		return 0, fmt.Errorf("expected number, got %T", raw)
+	}
+}
+
+// toDuration accepts Go duration strings ("15s") or a number of seconds
+func toDuration(raw interface{}) (time.Duration, error) {
+	switch r := raw.(type) {
+	case string:
+		d, err := time.ParseDuration(strings.TrimSpace(r))
+		if err != nil {
+			return 0, fmt.Errorf("expected duration, got %q", r)
+		}
+		return d, nil
+	case int, int64, float64:
+		f, _ := toFloat(r)
+		return time.Duration(f * float64(time.Second)), nil
+	default:
+		return 0, fmt.Errorf("expected duration, got %T", raw)
+	}
+}
+
+// toStrings accepts a list or a comma-separated string
+func toStrings(raw interface{}) ([]string, error) {
+	switch r := raw.(type) {
+	case string:
+		var items []string
+		for _, item := range strings.Split(r, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		return items, nil
+	case []string:
+		return r, nil
+	case []interface{}:
+		items := make([]string, len(r))
+		for i, item := range r {
+			s, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("expected list of strings, got %T at index %d", item, i)
+			}
+			items[i] = s
+		}
+		return items, nil
+	default:
+		return nil, fmt.Errorf("expected list of strings, got %T", raw)
+	}
+}
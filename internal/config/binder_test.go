@@ -0,0 +1,159 @@
+package config
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type bindTarget struct {
+	Name    string        `config:"name" default:"app"`
+	Port    int           `config:"port" default:"8080"`
+	Small   int8          `config:"small"`
+	Count   uint          `config:"count"`
+	Ratio   float64       `config:"ratio"`
+	Enabled bool          `config:"enabled"`
+	Timeout time.Duration `config:"timeout" default:"15s"`
+	Tags    []string      `config:"tags"`
+	Skipped string        `config:"-"`
+	Nested  struct {
+		Host string `config:"host" default:"localhost"`
+		Deep string `config:"a.b"`
+	} `config:"nested"`
+}
+
+func TestBindCoercion(t *testing.T) {
+	tests := []struct {
+		name  string
+		data  map[string]interface{}
+		check func(t *testing.T, got *bindTarget)
+	}{
+		{
+			name: "defaults for missing keys",
+			data: map[string]interface{}{},
+			check: func(t *testing.T, got *bindTarget) {
+				if got.Name != "app" || got.Port != 8080 || got.Timeout != 15*time.Second || got.Nested.Host != "localhost" {
+					t.Errorf("got %+v", got)
+				}
+			},
+		},
+		{
+			name: "nil falls back to the default",
+			data: map[string]interface{}{"port": nil},
+			check: func(t *testing.T, got *bindTarget) {
+				if got.Port != 8080 {
+					t.Errorf("port: got %d, want 8080", got.Port)
+				}
+			},
+		},
+		{
+			name: "strings from environment variables",
+			data: map[string]interface{}{
+				"port": " 9000 ", "count": "3", "ratio": "0.5", "enabled": "true",
+				"timeout": "2m", "tags": "a, b,,c",
+			},
+			check: func(t *testing.T, got *bindTarget) {
+				if got.Port != 9000 || got.Count != 3 || got.Ratio != 0.5 || !got.Enabled || got.Timeout != 2*time.Minute {
+					t.Errorf("got %+v", got)
+				}
+				if want := []string{"a", "b", "c"}; !reflect.DeepEqual(got.Tags, want) {
+					t.Errorf("tags: got %v, want %v", got.Tags, want)
+				}
+			},
+		},
+		{
+			name: "JSON numbers and lists",
+			data: map[string]interface{}{
+				"name": 42.0, "port": 9000.0, "ratio": 2, "timeout": 1.5,
+				"tags": []interface{}{"x", "y"},
+			},
+			check: func(t *testing.T, got *bindTarget) {
+				if got.Name != "42" || got.Port != 9000 || got.Ratio != 2 || got.Timeout != 1500*time.Millisecond {
+					t.Errorf("got %+v", got)
+				}
+				if want := []string{"x", "y"}; !reflect.DeepEqual(got.Tags, want) {
+					t.Errorf("tags: got %v, want %v", got.Tags, want)
+				}
+			},
+		},
+		{
+			name: "nested sections and dotted tags",
+			data: map[string]interface{}{
+				"nested": map[string]interface{}{
+					"host": "db",
+					"a":    map[string]interface{}{"b": "deep"},
+				},
+			},
+			check: func(t *testing.T, got *bindTarget) {
+				if got.Nested.Host != "db" || got.Nested.Deep != "deep" {
+					t.Errorf("nested: got %+v", got.Nested)
+				}
+			},
+		},
+		{
+			name: "skipped fields are not bound",
+			data: map[string]interface{}{"-": "x", "skipped": "x"},
+			check: func(t *testing.T, got *bindTarget) {
+				if got.Skipped != "" {
+					t.Errorf("skipped: got %q", got.Skipped)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got bindTarget
+			if err := Bind(tt.data, &got); err != nil {
+				t.Fatalf("Bind: %v", err)
+			}
+			tt.check(t, &got)
+		})
+	}
+}
+
+func TestBindAggregatesErrors(t *testing.T) {
+	data := map[string]interface{}{
+		"port":    "tcp://10.0.0.1:80",
+		"small":   300,
+		"count":   -1,
+		"ratio":   "half",
+		"enabled": "maybe",
+		"timeout": "soon",
+		"tags":    []interface{}{"a", 1},
+		"nested":  "db",
+	}
+
+	var got bindTarget
+	err := Bind(data, &got)
+	var bindErr *BindError
+	if !errors.As(err, &bindErr) {
+		t.Fatalf("Bind: got %v, want *BindError", err)
+	}
+
+	want := map[string]string{
+		"port":    `expected integer, got "tcp://10.0.0.1:80"`,
+		"small":   "value 300 out of range",
+		"count":   "value -1 out of range",
+		"ratio":   `expected number, got "half"`,
+		"enabled": `expected boolean, got "maybe"`,
+		"timeout": `expected duration, got "soon"`,
+		"tags":    "expected list of strings, got int at index 1",
+		"nested":  "expected object, got string",
+	}
+	gotErrs := make(map[string]string)
+	for _, fieldErr := range bindErr.Errors {
+		gotErrs[fieldErr.Key] = fieldErr.Message
+	}
+	if !reflect.DeepEqual(gotErrs, want) {
+		t.Errorf("errors:\n got  %v\n want %v", gotErrs, want)
+	}
+}
+
+func TestBindRejectsNonStructTarget(t *testing.T) {
+	var n int
+	if err := Bind(map[string]interface{}{}, &n); err == nil {
+		t.Fatal("Bind into *int: got nil error")
+	}
+}
@@ -1,44 +1,264 @@
 package config
 
-import "os"
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"time"
+)
 
 type Config struct {
-	Database DatabaseConfig
-	Server   ServerConfig
-	Cache    CacheConfig
-	Vault    VaultConfig
+	Database   DatabaseConfig   `config:"database"`
+	Server     ServerConfig     `config:"server"`
+	Management ManagementConfig `config:"management"`
+	Cache      CacheConfig      `config:"cache"`
+	Reload     ReloadConfig     `config:"reload"`
+	Startup    StartupConfig    `config:"startup"`
+	Vault      VaultConfig      `config:"-"`
+	Profiles   []string         `config:"-"`
+
+	// origins maps each key to the source its value was read from
+	origins map[string]string
 }
 
 type DatabaseConfig struct {
-	URL string
+	URL string `config:"url" validate:"required" secret:"url"`
+
+	// CredentialsRole requests short-lived credentials for this role from
+	// the Vault database secrets engine at CredentialsMount. They replace
+	// any user info in URL and are rotated before the lease expires.
+	CredentialsRole  string `config:"credentials_role"`
+	CredentialsMount string `config:"credentials_mount" default:"database"`
+}
+
+// Validate checks that the database URL is a PostgreSQL connection URL
+func (c *DatabaseConfig) Validate() []FieldError {
+	if c.URL == "" {
+		return nil
+	}
+	u, err := url.Parse(c.URL)
+	if err != nil {
+		return []FieldError{{Key: "url", Message: "is not a valid URL"}}
+	}
+	if u.Scheme != "postgres" && u.Scheme != "postgresql" {
+		return []FieldError{{Key: "url", Message: fmt.Sprintf("unsupported scheme %q, expected postgres", u.Scheme)}}
+	}
+	if u.Host == "" {
+		return []FieldError{{Key: "url", Message: "is missing a host"}}
+	}
+	return nil
 }
 
 type ServerConfig struct {
-	Host string
-	Port int
+	Host string `config:"host"`
+	Port int    `config:"port" default:"8080" validate:"min=1,max=65535"`
+
+	// HandlerTimeout bounds every handler unless its route sets its own
+	// timeout, and MaxBodyBytes bounds every request body. Zero disables
+	// the limit. The handler timeout is below the write timeout, so that
+	// the 503 is written before the connection is closed.
+	HandlerTimeout time.Duration `config:"handler_timeout" default:"10s" validate:"min=0s"`
+	MaxBodyBytes   int64         `config:"max_body_bytes" default:"1048576" validate:"min=0"`
+
+	// Connection timeouts and header limit of the HTTP server. A zero
+	// timeout means no timeout.
+	ReadTimeout       time.Duration `config:"read_timeout" default:"15s" validate:"min=0s"`
+	ReadHeaderTimeout time.Duration `config:"read_header_timeout" default:"5s" validate:"min=0s"`
+	WriteTimeout      time.Duration `config:"write_timeout" default:"15s" validate:"min=0s"`
+	IdleTimeout       time.Duration `config:"idle_timeout" default:"60s" validate:"min=0s"`
+	MaxHeaderBytes    int           `config:"max_header_bytes" default:"1048576" validate:"min=1"`
+
+	// H2C serves HTTP/2 without TLS, for traffic inside a trusted network
+	H2C bool      `config:"h2c"`
+	TLS TLSConfig `config:"tls"`
+}
+
+// TLSConfig enables HTTPS when CertFile and KeyFile are set. The files are
+// checked for changes every reload.interval and reloaded without a
+// restart. ClientCAFile enables mutual TLS: ClientAuth "require" rejects
+// clients without a certificate signed by one of its CAs and "optional"
+// only verifies certificates that clients present.
+type TLSConfig struct {
+	CertFile     string `config:"cert_file"`
+	KeyFile      string `config:"key_file"`
+	ClientCAFile string `config:"client_ca_file"`
+	ClientAuth   string `config:"client_auth" default:"none" validate:"oneof=none optional require"`
+	MinVersion   string `config:"min_version" default:"1.2" validate:"oneof=1.2 1.3"`
+}
+
+// Enabled reports whether the server uses TLS
+func (c *TLSConfig) Enabled() bool {
+	return c.CertFile != ""
+}
+
+// Validate checks that the certificate settings are complete
+func (c *TLSConfig) Validate() []FieldError {
+	var errs []FieldError
+	if (c.CertFile == "") != (c.KeyFile == "") {
+		errs = append(errs, FieldError{Key: "cert_file", Message: "cert_file and key_file must be set together"})
+	}
+	if c.ClientAuth != "none" && c.ClientCAFile == "" {
+		errs = append(errs, FieldError{Key: "client_ca_file", Message: fmt.Sprintf("is required when client_auth is %s", c.ClientAuth)})
+	}
+	if c.ClientCAFile != "" && !c.Enabled() {
+		errs = append(errs, FieldError{Key: "client_ca_file", Message: "requires cert_file and key_file"})
+	}
+	return errs
+}
+
+// Validate checks that TLS and h2c are not combined and that the handler
+// timeout can fire before the write timeout
+func (c *ServerConfig) Validate() []FieldError {
+	var errs []FieldError
+	if c.H2C && c.TLS.Enabled() {
+		errs = append(errs, FieldError{Key: "h2c", Message: "cannot be combined with tls, which negotiates HTTP/2 itself"})
+	}
+	// The connection would be closed before the timeout middleware responds
+	if c.HandlerTimeout > 0 && c.WriteTimeout > 0 && c.HandlerTimeout >= c.WriteTimeout {
+		errs = append(errs, FieldError{Key: "handler_timeout", Message: fmt.Sprintf("must be less than write_timeout %s", c.WriteTimeout)})
+	}
+	return errs
+}
+
+// ManagementConfig is the listener serving health, metrics, profiling and
+// actuator endpoints apart from the public API. Pprof enables the
+// /debug/pprof endpoints, which are off by default as they expose the
+// command line and runtime internals.
+type ManagementConfig struct {
+	Host  string `config:"host"`
+	Port  int    `config:"port" default:"8081" validate:"min=1,max=65535"`
+	Pprof bool   `config:"pprof" default:"false"`
+}
+
+// Validate checks that the management and public listeners do not share a
+// port
+func (c *Config) Validate() []FieldError {
+	if c.Management.Port == c.Server.Port && hostsOverlap(c.Management.Host, c.Server.Host) {
+		return []FieldError{{Key: "management.port", Message: fmt.Sprintf("must differ from server.port %d", c.Server.Port)}}
+	}
+	return nil
+}
+
+// hostsOverlap reports whether listeners on a and b would bind the same
+// address for a port. An empty or wildcard host listens on every interface.
+func hostsOverlap(a, b string) bool {
+	return a == b || isWildcardHost(a) || isWildcardHost(b)
+}
+
+func isWildcardHost(host string) bool {
+	switch host {
+	case "", "0.0.0.0", "::", "[::]":
+		return true
+	default:
+		return false
+	}
 }
 
 type CacheConfig struct {
-	Type  string
-	Redis RedisConfig
+	Type  string      `config:"type" default:"memory" validate:"oneof=memory redis"`
+	Redis RedisConfig `config:"redis"`
+}
+
+// Validate checks the Redis settings when the Redis cache is selected
+func (c *CacheConfig) Validate() []FieldError {
+	if c.Type != "redis" {
+		return nil
+	}
+	var errs []FieldError
+	if c.Redis.Host == "" {
+		errs = append(errs, FieldError{Key: "redis.host", Message: "is required when cache.type is redis"})
+	}
+	return errs
 }
 
 type RedisConfig struct {
-	Host     string
-	Port     int
-	Password string
-	DB       int
+	Host     string `config:"host" default:"localhost"`
+	Port     int    `config:"port" default:"6379" validate:"min=1,max=65535"`
+	Password string `config:"password" secret:"true"`
+	DB       int    `config:"db" default:"0" validate:"min=0"`
+}
+
+// ReloadConfig controls how often the configuration sources are checked for
+// changes. An interval of zero disables hot reload.
+type ReloadConfig struct {
+	Interval time.Duration `config:"interval" default:"30s" validate:"min=0s"`
+}
+
+// StartupConfig controls the dependency checks run before the server starts.
+// Each check is retried with exponential backoff from InitialDelay up to
+// MaxDelay, and each attempt is bounded by Timeout. Dependencies listed in
+// Optional (the cache by default) only degrade the application when they
+// are down; any other failing dependency stops startup. Zero attempts skips
+// the checks.
+type StartupConfig struct {
+	Attempts     int           `config:"attempts" default:"5" validate:"min=0"`
+	InitialDelay time.Duration `config:"initial_delay" default:"500ms" validate:"min=0s"`
+	MaxDelay     time.Duration `config:"max_delay" default:"10s" validate:"min=0s"`
+	Timeout      time.Duration `config:"timeout" default:"5s" validate:"min=0s"`
+	Optional     []string      `config:"optional" default:"cache"`
 }
 
+// Validate checks that retries wait between attempts, since the backoff
+// doubles the initial delay
+func (c *StartupConfig) Validate() []FieldError {
+	if c.Attempts > 1 && c.InitialDelay <= 0 {
+		return []FieldError{{Key: "initial_delay", Message: "must be positive when attempts is greater than 1"}}
+	}
+	return nil
+}
+
+// LoadConfig loads configuration from all default sources
 func LoadConfig() (*Config, error) {
-	vaultConfig := &VaultConfig{
-		Address:    getEnvOrDefault("VAULT_ADDR", "http://localhost:8200"),
-		Token:      getEnvOrDefault("VAULT_TOKEN", ""),
-		MountPath:  getEnvOrDefault("VAULT_MOUNT_PATH", "secret"),
-		SecretPath: getEnvOrDefault("VAULT_SECRET_PATH", "go-spring/config"),
+	return NewDefaultLoader().Load()
+}
+
+// EnvPrefix is the prefix of the environment variables configuration keys
+// are read from, e.g. APP_SERVER_PORT for server.port. Without it, keys
+// would collide with variables such as the SERVER_PORT Kubernetes injects
+// for a Service named server.
+const EnvPrefix = "APP_"
+
+// NewDefaultLoader creates a loader reading, in order of increasing
+// precedence, built-in defaults, the config file (CONFIG_FILE, default
+// config.json), environment variables prefixed with EnvPrefix and, when
+// VAULT_ADDR is set, Vault.
+// For every profile in APP_PROFILES an optional overlay is applied after
+// the config file (config-<profile>.json) and after the Vault secret
+// (<secret path>-<profile>).
+func NewDefaultLoader() *Loader {
+	profiles := ActiveProfiles()
+	configFile := getEnvOrDefault("CONFIG_FILE", "config.json")
+
+	sources := []Source{
+		NewMapSource("defaults", DefaultValues()),
+		NewFileSource(configFile, os.Getenv("CONFIG_FILE") != ""),
+	}
+	for _, profile := range profiles {
+		sources = append(sources, NewFileSource(profilePath(configFile, profile), false))
+	}
+	sources = append(sources, NewEnvSource(EnvPrefix))
+
+	if os.Getenv("VAULT_ADDR") != "" {
+		sources = append(sources, NewVaultSource(&VaultConfig{
+			Address:      os.Getenv("VAULT_ADDR"),
+			Token:        getEnvOrDefault("VAULT_TOKEN", ""),
+			MountPath:    getEnvOrDefault("VAULT_MOUNT_PATH", "secret"),
+			SecretPath:   getEnvOrDefault("VAULT_SECRET_PATH", "go-spring/config"),
+			AuthMethod:   getEnvOrDefault("VAULT_AUTH_METHOD", VaultAuthToken),
+			AuthMount:    getEnvOrDefault("VAULT_AUTH_MOUNT", ""),
+			TokenFile:    getEnvOrDefault("VAULT_TOKEN_FILE", ""),
+			RoleID:       getEnvOrDefault("VAULT_ROLE_ID", ""),
+			SecretID:     getEnvOrDefault("VAULT_SECRET_ID", ""),
+			SecretIDFile: getEnvOrDefault("VAULT_SECRET_ID_FILE", ""),
+			Role:         getEnvOrDefault("VAULT_ROLE", ""),
+			JWTPath:      getEnvOrDefault("VAULT_JWT_PATH", ""),
+			CacheFile:    getEnvOrDefault("VAULT_CACHE_FILE", ""),
+			CacheKey:     getEnvOrDefault("VAULT_CACHE_KEY", ""),
+			CacheKeyFile: getEnvOrDefault("VAULT_CACHE_KEY_FILE", ""),
+		}, profiles...))
 	}
 
-	return LoadFromVault(vaultConfig)
+	return NewLoader(sources...).WithProfiles(profiles)
 }
 
 func getEnvOrDefault(key, defaultValue string) string {
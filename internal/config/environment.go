@@ -0,0 +1,137 @@
+package config
+
+import (
+	"net/url"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// maskedValue replaces secrets in diagnostic output
+const maskedValue = "******"
+
+// Property is a single effective configuration value and where it came from
+type Property struct {
+	Key    string      `json:"key"`
+	Value  interface{} `json:"value"`
+	Source string      `json:"source"`
+}
+
+// Environment describes the effective configuration for diagnostics.
+// Secrets are masked: fields tagged `secret:"true"` are replaced entirely
+// and fields tagged `secret:"url"` have the password in their URL removed.
+type Environment struct {
+	Profiles   []string          `json:"profiles"`
+	Vault      *VaultDescription `json:"vault,omitempty"`
+	Properties []Property        `json:"properties"`
+}
+
+// VaultDescription lists the non-secret Vault settings in use
+type VaultDescription struct {
+	Address    string `json:"address"`
+	MountPath  string `json:"mount_path"`
+	SecretPath string `json:"secret_path"`
+	AuthMethod string `json:"auth_method"`
+}
+
+// Environment returns the effective configuration with secrets masked
+func (c *Config) Environment() *Environment {
+	env := &Environment{
+		Profiles: c.Profiles,
+	}
+	if len(env.Profiles) == 0 {
+		env.Profiles = []string{DefaultProfile}
+	}
+
+	if c.Vault.Address != "" {
+		authMethod := c.Vault.AuthMethod
+		if authMethod == "" {
+			authMethod = VaultAuthToken
+		}
+		env.Vault = &VaultDescription{
+			Address:    c.Vault.Address,
+			MountPath:  c.Vault.MountPath,
+			SecretPath: c.Vault.SecretPath,
+			AuthMethod: authMethod,
+		}
+	}
+
+	secrets := make(map[string]string)
+	walkFields(reflect.TypeOf(c), "", func(key string, field reflect.StructField) {
+		if secret := field.Tag.Get("secret"); secret != "" {
+			secrets[key] = secret
+		}
+	})
+
+	for key, value := range Values(c) {
+		source, ok := c.origins[key]
+		if !ok {
+			source = "defaults"
+		}
+		if d, ok := value.(time.Duration); ok {
+			value = d.String()
+		}
+		env.Properties = append(env.Properties, Property{
+			Key:    key,
+			Value:  maskValue(value, secrets[key]),
+			Source: source,
+		})
+	}
+	sort.Slice(env.Properties, func(i, j int) bool {
+		return env.Properties[i].Key < env.Properties[j].Key
+	})
+
+	return env
+}
+
+// maskValue hides a secret value according to its secret tag
+func maskValue(value interface{}, secret string) interface{} {
+	switch secret {
+	case "":
+		return value
+	case "url":
+		raw, ok := value.(string)
+		if !ok || raw == "" {
+			return value
+		}
+		return MaskURL(raw)
+	default:
+		if reflect.ValueOf(value).IsZero() {
+			return value
+		}
+		return maskedValue
+	}
+}
+
+// maskedURLParams are the query parameters of a URL holding credentials
+var maskedURLParams = []string{"user", "password", "sslpassword"}
+
+// MaskURL hides the credentials in a URL, e.g. a database connection URL:
+// the whole user info and the credential query parameters
+func MaskURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return maskedValue
+	}
+	// URL encoding would escape the asterisks, so the masked parts are
+	// written with a placeholder and replaced afterwards
+	const placeholder = "MASKED"
+	var replacements []string
+	if u.User != nil {
+		u.User = url.User(placeholder)
+		replacements = append(replacements, "//"+placeholder+"@", "//"+maskedValue+"@")
+	}
+	query := u.Query()
+	for _, param := range maskedURLParams {
+		if query.Has(param) {
+			query.Set(param, placeholder)
+			u.RawQuery = query.Encode()
+			replacements = append(replacements, param+"="+placeholder, param+"="+maskedValue)
+		}
+	}
+	if len(replacements) == 0 {
+		return raw
+	}
+	return strings.NewReplacer(replacements...).Replace(u.String())
+}
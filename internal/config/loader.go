@@ -0,0 +1,165 @@
+package config
+
+import (
+	"fmt"
+	"io"
+)
+
+// Source provides one layer of configuration values. The returned map is
+// keyed by lower-case section names and mirrors the layout of config.json,
+// e.g. {"cache": {"redis": {"port": 6379}}}.
+type Source interface {
+	Name() string
+	Load() (map[string]interface{}, error)
+}
+
+// OriginDescriber is implemented by sources that can describe more
+// precisely where a key was read from, e.g. an environment variable name or
+// a Vault secret path and version
+type OriginDescriber interface {
+	Origin(key string) string
+}
+
+// Loader merges configuration sources into a single Config. Sources are
+// applied in order, so values from later sources override earlier ones.
+type Loader struct {
+	sources  []Source
+	profiles []string
+}
+
+// NewLoader creates a loader for the given sources, lowest precedence first
+func NewLoader(sources ...Source) *Loader {
+	return &Loader{
+		sources: sources,
+	}
+}
+
+// WithProfiles records the active profiles on every loaded Config
+func (l *Loader) WithProfiles(profiles []string) *Loader {
+	l.profiles = profiles
+	return l
+}
+
+// Load reads every source, then decodes and validates the merged result
+func (l *Loader) Load() (*Config, error) {
+	merged := make(map[string]interface{})
+	origins := make(map[string]string)
+	for _, src := range l.sources {
+		data, err := src.Load()
+		if err != nil {
+			return nil, fmt.Errorf("failed to load config from %s: %w", src.Name(), err)
+		}
+		mergeMaps(merged, data)
+		recordOrigins(origins, data, "", originOf(src))
+	}
+
+	cfg, err := decodeConfig(merged)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Profiles = l.profiles
+	cfg.origins = origins
+	for _, src := range l.sources {
+		if vaultSource, ok := src.(*VaultSource); ok {
+			cfg.Vault = *vaultSource.config
+		}
+	}
+	return cfg, nil
+}
+
+// Versions returns the current version of every versioned source, keyed by
+// source name. Sources whose version cannot be read are left out, so they
+// are picked up again once they recover.
+func (l *Loader) Versions() map[string]int64 {
+	versions := make(map[string]int64)
+	for _, src := range l.sources {
+		versioned, ok := src.(Versioned)
+		if !ok {
+			continue
+		}
+		version, err := versioned.Version()
+		if err != nil {
+			continue
+		}
+		versions[src.Name()] = version
+	}
+	return versions
+}
+
+// originOf returns a function describing where src read a key from
+func originOf(src Source) func(key string) string {
+	if describer, ok := src.(OriginDescriber); ok {
+		return describer.Origin
+	}
+	name := src.Name()
+	return func(string) string {
+		return name
+	}
+}
+
+// recordOrigins notes the origin of every leaf key in data
+func recordOrigins(origins map[string]string, data map[string]interface{}, prefix string, origin func(key string) string) {
+	for key, value := range data {
+		path := joinKey(prefix, key)
+		if nested, ok := value.(map[string]interface{}); ok {
+			recordOrigins(origins, nested, path, origin)
+			continue
+		}
+		origins[path] = origin(path)
+	}
+}
+
+// mergeMaps deep-merges src into dst, overriding leaf values
+func mergeMaps(dst, src map[string]interface{}) {
+	for key, value := range src {
+		srcMap, srcIsMap := value.(map[string]interface{})
+		dstMap, dstIsMap := dst[key].(map[string]interface{})
+		if srcIsMap && dstIsMap {
+			mergeMaps(dstMap, srcMap)
+			continue
+		}
+		if srcIsMap {
+			copied := make(map[string]interface{})
+			mergeMaps(copied, srcMap)
+			dst[key] = copied
+			continue
+		}
+		dst[key] = value
+	}
+}
+
+// decodeConfig converts merged source data into a validated Config
+func decodeConfig(data map[string]interface{}) (*Config, error) {
+	cfg := &Config{}
+	if err := Bind(data, cfg); err != nil {
+		return nil, err
+	}
+	if err := Validate(cfg); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// VaultClient returns the client of the loader's Vault source, so other
+// components can share its authenticated, renewed token
+func (l *Loader) VaultClient() (*VaultClient, error) {
+	for _, src := range l.sources {
+		if vaultSource, ok := src.(*VaultSource); ok {
+			return vaultSource.Client()
+		}
+	}
+	return nil, fmt.Errorf("vault is not configured")
+}
+
+// Close releases resources held by sources, such as Vault token renewal
+func (l *Loader) Close() error {
+	var firstErr error
+	for _, src := range l.sources {
+		if closer, ok := src.(io.Closer); ok {
+			if err := closer.Close(); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
@@ -0,0 +1,58 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DefaultProfile is considered active when no profiles are set
+const DefaultProfile = "default"
+
+// ActiveProfiles returns the profiles listed in APP_PROFILES, e.g. "dev" or
+// "prod,eu". Later profiles take precedence over earlier ones.
+func ActiveProfiles() []string {
+	var profiles []string
+	for _, profile := range strings.Split(os.Getenv("APP_PROFILES"), ",") {
+		if profile = strings.TrimSpace(profile); profile != "" {
+			profiles = append(profiles, profile)
+		}
+	}
+	return profiles
+}
+
+// AcceptsProfiles reports whether any of the given profile expressions
+// matches the active profiles. An expression is a profile name or a name
+// prefixed with "!" to match when that profile is not active.
+func (c *Config) AcceptsProfiles(expressions ...string) bool {
+	for _, expr := range expressions {
+		if name, negated := strings.CutPrefix(expr, "!"); negated {
+			if !c.profileActive(name) {
+				return true
+			}
+		} else if c.profileActive(expr) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Config) profileActive(name string) bool {
+	if len(c.Profiles) == 0 {
+		return name == DefaultProfile
+	}
+	for _, profile := range c.Profiles {
+		if profile == name {
+			return true
+		}
+	}
+	return false
+}
+
+// profilePath returns the overlay for a profile next to path, e.g.
+// config.json becomes config-prod.json and go-spring/config becomes
+// go-spring/config-prod
+func profilePath(path, profile string) string {
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "-" + profile + ext
+}
@@ -0,0 +1,132 @@
+package config
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// SecretCache keeps the last configuration read from Vault in a file
+// encrypted with AES-GCM, so the application can still start when Vault is
+// unreachable
+type SecretCache struct {
+	path string
+	aead cipher.AEAD
+}
+
+// cachedSecret is the plaintext content of the cache file
+type cachedSecret struct {
+	Data    map[string]interface{} `json:"data"`
+	Origins map[string]string      `json:"origins"`
+	SavedAt time.Time              `json:"saved_at"`
+}
+
+// NewSecretCache creates a cache stored at path. The key must be 16, 24 or
+// 32 bytes long to select AES-128, AES-192 or AES-256.
+func NewSecretCache(path string, key []byte) (*SecretCache, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid secret cache key: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create secret cache cipher: %w", err)
+	}
+
+	return &SecretCache{
+		path: path,
+		aead: aead,
+	}, nil
+}
+
+// newSecretCacheFromConfig creates the cache configured in vaultConfig, or
+// returns nil if caching is disabled. The key is read from CacheKey as
+// base64 or from the file at CacheKeyFile.
+func newSecretCacheFromConfig(vaultConfig *VaultConfig) (*SecretCache, error) {
+	if vaultConfig.CacheFile == "" {
+		return nil, nil
+	}
+
+	encoded := vaultConfig.CacheKey
+	if vaultConfig.CacheKeyFile != "" {
+		var err error
+		if encoded, err = readSecretFile(vaultConfig.CacheKeyFile); err != nil {
+			return nil, fmt.Errorf("failed to read secret cache key file: %w", err)
+		}
+	}
+	if encoded == "" {
+		return nil, fmt.Errorf("secret cache requires a key")
+	}
+
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("secret cache key must be base64 encoded: %w", err)
+	}
+	return NewSecretCache(vaultConfig.CacheFile, key)
+}
+
+// Save encrypts and stores data, replacing the previous cache atomically
+func (c *SecretCache) Save(data map[string]interface{}, origins map[string]string) error {
+	plaintext, err := json.Marshal(cachedSecret{
+		Data:    data,
+		Origins: origins,
+		SavedAt: time.Now(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode secret cache: %w", err)
+	}
+
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	ciphertext := c.aead.Seal(nonce, nonce, plaintext, nil)
+
+	tmp, err := os.CreateTemp(filepath.Dir(c.path), filepath.Base(c.path)+".tmp")
+	if err != nil {
+		return fmt.Errorf("failed to write secret cache: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(ciphertext); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write secret cache: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write secret cache: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), c.path); err != nil {
+		return fmt.Errorf("failed to write secret cache: %w", err)
+	}
+	return nil
+}
+
+// Load decrypts the cached configuration
+func (c *SecretCache) Load() (*cachedSecret, error) {
+	content, err := os.ReadFile(c.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read secret cache: %w", err)
+	}
+
+	nonceSize := c.aead.NonceSize()
+	if len(content) < nonceSize {
+		return nil, fmt.Errorf("secret cache is corrupted")
+	}
+	plaintext, err := c.aead.Open(nil, content[:nonceSize], content[nonceSize:], nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt secret cache: %w", err)
+	}
+
+	var cached cachedSecret
+	if err := json.Unmarshal(plaintext, &cached); err != nil {
+		return nil, fmt.Errorf("failed to decode secret cache: %w", err)
+	}
+	return &cached, nil
+}
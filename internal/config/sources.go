@@ -0,0 +1,157 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// MapSource serves a fixed set of values, e.g. built-in defaults
+type MapSource struct {
+	name string
+	data map[string]interface{}
+}
+
+// NewMapSource creates a source backed by an in-memory map
+func NewMapSource(name string, data map[string]interface{}) *MapSource {
+	return &MapSource{
+		name: name,
+		data: data,
+	}
+}
+
+// Name returns the source name
+func (s *MapSource) Name() string {
+	return s.name
+}
+
+// Load returns the configured values
+func (s *MapSource) Load() (map[string]interface{}, error) {
+	return s.data, nil
+}
+
+// DefaultValues returns the built-in defaults declared by the `default`
+// tags on Config
+func DefaultValues() map[string]interface{} {
+	return Defaults(&Config{})
+}
+
+// FileSource reads configuration from a JSON or YAML file
+type FileSource struct {
+	path     string
+	required bool
+}
+
+// NewFileSource creates a file source. A missing file is ignored unless
+// required is set.
+func NewFileSource(path string, required bool) *FileSource {
+	return &FileSource{
+		path:     path,
+		required: required,
+	}
+}
+
+// Name returns the source name
+func (s *FileSource) Name() string {
+	return "file:" + s.path
+}
+
+// Load parses the file based on its extension
+func (s *FileSource) Load() (map[string]interface{}, error) {
+	content, err := os.ReadFile(s.path)
+	if err != nil {
+		if os.IsNotExist(err) && !s.required {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	data := make(map[string]interface{})
+	switch strings.ToLower(filepath.Ext(s.path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(content, &data)
+	case ".json":
+		err = json.Unmarshal(content, &data)
+	default:
+		return nil, fmt.Errorf("unsupported config file format: %s", s.path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	}
+
+	// Files exported from Vault KV v2 wrap the values in a "data" key
+	if inner, ok := data["data"].(map[string]interface{}); ok && len(data) == 1 {
+		return inner, nil
+	}
+	return data, nil
+}
+
+// Version returns the file modification time, or 0 if the file is missing
+func (s *FileSource) Version() (int64, error) {
+	info, err := os.Stat(s.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	return info.ModTime().UnixNano(), nil
+}
+
+// EnvSource reads configuration from environment variables. A key such as
+// cache.redis.port is read from CACHE_REDIS_PORT, with an optional prefix.
+type EnvSource struct {
+	prefix string
+}
+
+// NewEnvSource creates an environment source
+func NewEnvSource(prefix string) *EnvSource {
+	return &EnvSource{
+		prefix: prefix,
+	}
+}
+
+// Name returns the source name
+func (s *EnvSource) Name() string {
+	return "env"
+}
+
+// Origin returns the environment variable a key is read from
+func (s *EnvSource) Origin(key string) string {
+	return "env:" + EnvName(s.prefix, key)
+}
+
+// Load collects the environment variables that are set
+func (s *EnvSource) Load() (map[string]interface{}, error) {
+	data := make(map[string]interface{})
+	for _, key := range Keys(&Config{}) {
+		if value, ok := os.LookupEnv(EnvName(s.prefix, key)); ok {
+			setPath(data, key, value)
+		}
+	}
+	return data, nil
+}
+
+// EnvName returns the environment variable name for a configuration key
+func EnvName(prefix, key string) string {
+	return prefix + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+// setPath stores value under a dotted key, creating nested maps as needed
+func setPath(data map[string]interface{}, key string, value interface{}) {
+	parts := strings.Split(key, ".")
+	current := data
+	for _, part := range parts[:len(parts)-1] {
+		next, ok := current[part].(map[string]interface{})
+		if !ok {
+			next = make(map[string]interface{})
+			current[part] = next
+		}
+		current = next
+	}
+	current[parts[len(parts)-1]] = value
+}
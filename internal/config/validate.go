@@ -0,0 +1,157 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Validator is implemented by configuration sections that need checks
+// beyond what `validate` tags can express. Keys in the returned errors are
+// relative to the section.
+type Validator interface {
+	Validate() []FieldError
+}
+
+// ValidationError lists every configuration rule that was violated
+type ValidationError struct {
+	Errors []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	return formatFieldErrors("configuration validation failed", e.Errors)
+}
+
+// Validate checks target against the rules in its `validate` tags and the
+// Validate methods of its sections, and reports all violations at once.
+// Supported rules are required, min=N, max=N (value for numbers, length for
+// strings) and oneof=a b c.
+func Validate(target interface{}) error {
+	v := reflect.ValueOf(target)
+	if v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return fmt.Errorf("validate target must be a struct, got %T", target)
+	}
+
+	var errs []FieldError
+	validateStruct(v, "", &errs)
+	if len(errs) > 0 {
+		return &ValidationError{Errors: errs}
+	}
+	return nil
+}
+
+func validateStruct(v reflect.Value, prefix string, errs *[]FieldError) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		key, ok := fieldKey(field)
+		if !ok {
+			continue
+		}
+		path := joinKey(prefix, key)
+
+		if isSection(field.Type) {
+			validateStruct(v.Field(i), path, errs)
+			continue
+		}
+
+		rules := field.Tag.Get("validate")
+		if rules == "" {
+			continue
+		}
+		for _, rule := range strings.Split(rules, ",") {
+			name, arg, _ := strings.Cut(strings.TrimSpace(rule), "=")
+			if msg := checkRule(v.Field(i), name, arg); msg != "" {
+				*errs = append(*errs, FieldError{Key: path, Message: msg})
+			}
+		}
+	}
+
+	if validator, ok := asValidator(v); ok {
+		for _, err := range validator.Validate() {
+			err.Key = joinKey(prefix, err.Key)
+			*errs = append(*errs, err)
+		}
+	}
+}
+
+func asValidator(v reflect.Value) (Validator, bool) {
+	if v.CanAddr() {
+		if validator, ok := v.Addr().Interface().(Validator); ok {
+			return validator, true
+		}
+	}
+	validator, ok := v.Interface().(Validator)
+	return validator, ok
+}
+
+func checkRule(v reflect.Value, name, arg string) string {
+	switch name {
+	case "required":
+		if v.IsZero() {
+			return "is required"
+		}
+	case "min", "max":
+		limit, err := ruleLimit(v, arg)
+		if err != nil {
+			return fmt.Sprintf("invalid %s rule %q", name, arg)
+		}
+		value, isLength := numericValue(v)
+		if name == "min" && value < limit {
+			if isLength {
+				return fmt.Sprintf("must be at least %s characters", arg)
+			}
+			return fmt.Sprintf("must be at least %s, got %v", arg, v.Interface())
+		}
+		if name == "max" && value > limit {
+			if isLength {
+				return fmt.Sprintf("must be at most %s characters", arg)
+			}
+			return fmt.Sprintf("must be at most %s, got %v", arg, v.Interface())
+		}
+	case "oneof":
+		allowed := strings.Fields(arg)
+		value := fmt.Sprintf("%v", v.Interface())
+		for _, a := range allowed {
+			if value == a {
+				return ""
+			}
+		}
+		return fmt.Sprintf("must be one of [%s], got %q", strings.Join(allowed, ", "), value)
+	default:
+		return fmt.Sprintf("unknown validation rule %q", name)
+	}
+	return ""
+}
+
+// ruleLimit parses a min/max argument, accepting durations for
+// time.Duration fields
+func ruleLimit(v reflect.Value, arg string) (float64, error) {
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(arg)
+		return float64(d), err
+	}
+	return strconv.ParseFloat(arg, 64)
+}
+
+// numericValue returns the number a min/max rule compares against and
+// whether it is a length rather than the value itself
+func numericValue(v reflect.Value) (float64, bool) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), false
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), false
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), false
+	case reflect.String, reflect.Slice, reflect.Map:
+		return float64(v.Len()), true
+	default:
+		return 0, false
+	}
+}
@@ -2,8 +2,13 @@ package config
 
 import (
 	"fmt"
+	"log"
+	"sync"
+	"time"
 
 	vault "github.com/hashicorp/vault/api"
+
+	"go-spring.com/internal/observability"
 )
 
 type VaultConfig struct {
@@ -11,60 +16,253 @@ type VaultConfig struct {
 	Token      string
 	MountPath  string
 	SecretPath string
+
+	// AuthMethod is one of token (default), token_file, approle or
+	// kubernetes. AuthMount overrides the auth mount path, which defaults
+	// to the method name.
+	AuthMethod string
+	AuthMount  string
+
+	// TokenFile is read by the token_file method
+	TokenFile string
+
+	// RoleID and SecretID (or SecretIDFile) are used by the approle method
+	RoleID       string
+	SecretID     string
+	SecretIDFile string
+
+	// Role and JWTPath are used by the kubernetes method. JWTPath defaults
+	// to the mounted service account token.
+	Role    string
+	JWTPath string
+
+	// CacheFile enables an encrypted copy of the last secret read, used
+	// when Vault is unreachable. The AES key is given base64 encoded in
+	// CacheKey or in the file at CacheKeyFile.
+	CacheFile    string
+	CacheKey     string
+	CacheKeyFile string
 }
 
 func LoadFromVault(vaultConfig *VaultConfig) (*Config, error) {
-	// Create Vault client
-	config := vault.DefaultConfig()
-	config.Address = vaultConfig.Address
+	loader := NewLoader(NewVaultSource(vaultConfig))
+	defer loader.Close()
+	return loader.Load()
+}
+
+// VaultSource reads configuration from a Vault KV v2 secret, followed by
+// optional per-profile overlays stored next to it
+type VaultSource struct {
+	config   *VaultConfig
+	profiles []string
+	mu       sync.Mutex
+	client   *VaultClient
+	origins  map[string]string
+}
+
+// NewVaultSource creates a Vault-backed configuration source. For each
+// profile, the secret at "<SecretPath>-<profile>" is merged on top of the
+// base secret if it exists.
+func NewVaultSource(vaultConfig *VaultConfig, profiles ...string) *VaultSource {
+	return &VaultSource{
+		config:   vaultConfig,
+		profiles: profiles,
+	}
+}
+
+// Name returns the source name
+func (s *VaultSource) Name() string {
+	return fmt.Sprintf("vault:%s/%s", s.config.MountPath, s.config.SecretPath)
+}
+
+// Load reads the secret data from Vault and merges the profile overlays.
+// When a secret cache is configured, successful reads are stored in it and
+// used instead if Vault cannot be reached.
+func (s *VaultSource) Load() (map[string]interface{}, error) {
+	secretCache, err := newSecretCacheFromConfig(s.config)
+	if err != nil {
+		return nil, err
+	}
+
+	data, origins, err := s.read()
+	if err != nil {
+		if secretCache == nil {
+			return nil, err
+		}
+		return s.loadCached(secretCache, err)
+	}
+
+	if secretCache != nil {
+		if err := secretCache.Save(data, origins); err != nil {
+			log.Printf("Failed to update secret cache: %v", err)
+		}
+	}
+	observability.ConfigStale.Set(0)
 
-	client, err := vault.NewClient(config)
+	s.mu.Lock()
+	s.origins = origins
+	s.mu.Unlock()
+
+	return data, nil
+}
+
+// loadCached returns the last configuration read from Vault
+func (s *VaultSource) loadCached(secretCache *SecretCache, vaultErr error) (map[string]interface{}, error) {
+	cached, err := secretCache.Load()
 	if err != nil {
-		return nil, fmt.Errorf("failed to create vault client: %w", err)
+		return nil, fmt.Errorf("%w (secret cache unavailable: %v)", vaultErr, err)
+	}
+
+	log.Printf("WARNING: Vault is unreachable, running on stale configuration cached at %s: %v",
+		cached.SavedAt.Format(time.RFC3339), vaultErr)
+	observability.ConfigStale.Set(1)
+
+	origins := make(map[string]string, len(cached.Origins))
+	for key, origin := range cached.Origins {
+		origins[key] = origin + " (cached)"
 	}
+	s.mu.Lock()
+	s.origins = origins
+	s.mu.Unlock()
 
-	// Set token
-	client.SetToken(vaultConfig.Token)
+	return cached.Data, nil
+}
 
+// read loads the base secret and profile overlays from Vault
+func (s *VaultSource) read() (map[string]interface{}, map[string]string, error) {
+	client, err := s.getClient()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	data := make(map[string]interface{})
+	origins := make(map[string]string)
+	for i, path := range s.paths() {
+		secret, version, err := s.readSecret(client, path)
+		if err != nil {
+			return nil, nil, err
+		}
+		if secret == nil {
+			if i == 0 {
+				return nil, nil, fmt.Errorf("no data found at path: %s", path)
+			}
+			continue
+		}
+		mergeMaps(data, secret)
+		origin := fmt.Sprintf("vault:%s/%s@v%d", s.config.MountPath, path, version)
+		recordOrigins(origins, secret, "", func(string) string {
+			return origin
+		})
+	}
+
+	return data, origins, nil
+}
+
+// Origin returns the secret path and version a key was last read from
+func (s *VaultSource) Origin(key string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if origin, ok := s.origins[key]; ok {
+		return origin
+	}
+	return s.Name()
+}
+
+// readSecret returns the data and version of a secret, or nil if it does
+// not exist
+func (s *VaultSource) readSecret(client *vault.Client, path string) (map[string]interface{}, int64, error) {
 	// Read secret
-	secret, err := client.Logical().Read(fmt.Sprintf("%s/data/%s", vaultConfig.MountPath, vaultConfig.SecretPath))
+	secret, err := client.Logical().Read(fmt.Sprintf("%s/data/%s", s.config.MountPath, path))
 	if err != nil {
-		return nil, fmt.Errorf("failed to read secret: %w", err)
+		return nil, 0, fmt.Errorf("failed to read secret: %w", err)
 	}
 
 	if secret == nil || secret.Data == nil {
-		return nil, fmt.Errorf("no data found at path: %s", vaultConfig.SecretPath)
+		return nil, 0, nil
 	}
 
 	// Extract data from secret
 	data, ok := secret.Data["data"].(map[string]interface{})
 	if !ok {
-		return nil, fmt.Errorf("invalid secret data format")
+		return nil, 0, fmt.Errorf("invalid secret data format at path: %s", path)
+	}
+
+	var version int64
+	if metadata, ok := secret.Data["metadata"].(map[string]interface{}); ok {
+		version, _ = jsonInt64(metadata["version"])
 	}
 
-	// Convert to Config struct
-	cfg := &Config{}
+	return data, version, nil
+}
 
-	// Database config
-	if db, ok := data["database"].(map[string]interface{}); ok {
-		cfg.Database.URL = db["url"].(string)
+// Version returns the sum of the current KV v2 versions of the secret and
+// its profile overlays, which grows whenever any of them is updated
+func (s *VaultSource) Version() (int64, error) {
+	client, err := s.getClient()
+	if err != nil {
+		return 0, err
 	}
 
-	// Server config
-	if server, ok := data["server"].(map[string]interface{}); ok {
-		cfg.Server.Port = int(server["port"].(float64))
+	var total int64
+	for _, path := range s.paths() {
+		secret, err := client.Logical().Read(fmt.Sprintf("%s/metadata/%s", s.config.MountPath, path))
+		if err != nil {
+			return 0, fmt.Errorf("failed to read secret metadata: %w", err)
+		}
+		if secret == nil || secret.Data == nil {
+			continue
+		}
+
+		version, ok := jsonInt64(secret.Data["current_version"])
+		if !ok {
+			return 0, fmt.Errorf("invalid secret metadata format at path: %s", path)
+		}
+		total += version
+	}
+	return total, nil
+}
+
+// paths returns the base secret path followed by the profile overlays
+func (s *VaultSource) paths() []string {
+	paths := []string{s.config.SecretPath}
+	for _, profile := range s.profiles {
+		paths = append(paths, profilePath(s.config.SecretPath, profile))
 	}
+	return paths
+}
+
+// Close stops renewal of the Vault token
+func (s *VaultSource) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-	// Cache config
-	if cache, ok := data["cache"].(map[string]interface{}); ok {
-		cfg.Cache.Type = cache["type"].(string)
-		if redis, ok := cache["redis"].(map[string]interface{}); ok {
-			cfg.Cache.Redis.Host = redis["host"].(string)
-			cfg.Cache.Redis.Port = int(redis["port"].(float64))
-			cfg.Cache.Redis.Password = redis["password"].(string)
-			cfg.Cache.Redis.DB = int(redis["db"].(float64))
+	if s.client == nil {
+		return nil
+	}
+	err := s.client.Close()
+	s.client = nil
+	return err
+}
+
+// Client returns the authenticated Vault client, creating it on first use
+func (s *VaultSource) Client() (*VaultClient, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.client == nil {
+		client, err := NewVaultClient(s.config)
+		if err != nil {
+			return nil, err
 		}
+		s.client = client
 	}
+	return s.client, nil
+}
 
-	return cfg, nil
+func (s *VaultSource) getClient() (*vault.Client, error) {
+	client, err := s.Client()
+	if err != nil {
+		return nil, err
+	}
+	return client.Client(), nil
 }
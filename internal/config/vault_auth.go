@@ -0,0 +1,297 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	vault "github.com/hashicorp/vault/api"
+)
+
+// Supported Vault authentication methods
+const (
+	VaultAuthToken      = "token"
+	VaultAuthTokenFile  = "token_file"
+	VaultAuthAppRole    = "approle"
+	VaultAuthKubernetes = "kubernetes"
+)
+
+// defaultKubernetesJWTPath is where Kubernetes mounts the service account token
+const defaultKubernetesJWTPath = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+
+// VaultClient is an authenticated Vault client. Tokens obtained from a
+// login are renewed in the background for as long as the client is open,
+// and the client logs in again once its token can no longer be renewed.
+type VaultClient struct {
+	client *vault.Client
+	config *VaultConfig
+	stop   chan struct{}
+	done   chan struct{}
+	once   sync.Once
+}
+
+// NewVaultClient creates a client and authenticates with the method
+// configured in vaultConfig.AuthMethod
+func NewVaultClient(vaultConfig *VaultConfig) (*VaultClient, error) {
+	config := vault.DefaultConfig()
+	config.Address = vaultConfig.Address
+
+	client, err := vault.NewClient(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create vault client: %w", err)
+	}
+
+	c := &VaultClient{
+		client: client,
+		config: vaultConfig,
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+
+	auth, err := c.login()
+	if err != nil {
+		return nil, err
+	}
+	go c.manageToken(auth)
+
+	return c, nil
+}
+
+// Client returns the underlying Vault API client
+func (c *VaultClient) Client() *vault.Client {
+	return c.client
+}
+
+// Close stops token renewal
+func (c *VaultClient) Close() error {
+	c.once.Do(func() {
+		close(c.stop)
+	})
+	<-c.done
+	return nil
+}
+
+// DatabaseCredentials requests short-lived credentials for role from the
+// database secrets engine mounted at mount. The returned secret holds the
+// username and password in Data along with the lease to renew.
+func (c *VaultClient) DatabaseCredentials(mount, role string) (*vault.Secret, error) {
+	secret, err := c.client.Logical().Read(fmt.Sprintf("%s/creds/%s", mount, role))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read database credentials: %w", err)
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, fmt.Errorf("no database credentials returned for role: %s", role)
+	}
+	return secret, nil
+}
+
+// login authenticates and returns the token lease to manage, or nil when
+// the token does not expire
+func (c *VaultClient) login() (*vault.Secret, error) {
+	switch c.authMethod() {
+	case VaultAuthToken:
+		c.client.SetToken(c.config.Token)
+		return c.lookupToken()
+	case VaultAuthTokenFile:
+		token, err := readSecretFile(c.config.TokenFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read vault token file: %w", err)
+		}
+		c.client.SetToken(token)
+		return c.lookupToken()
+	case VaultAuthAppRole:
+		secretID := c.config.SecretID
+		if c.config.SecretIDFile != "" {
+			var err error
+			if secretID, err = readSecretFile(c.config.SecretIDFile); err != nil {
+				return nil, fmt.Errorf("failed to read approle secret id file: %w", err)
+			}
+		}
+		return c.loginWith(map[string]interface{}{
+			"role_id":   c.config.RoleID,
+			"secret_id": secretID,
+		})
+	case VaultAuthKubernetes:
+		jwtPath := c.config.JWTPath
+		if jwtPath == "" {
+			jwtPath = defaultKubernetesJWTPath
+		}
+		jwt, err := readSecretFile(jwtPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read kubernetes service account token: %w", err)
+		}
+		return c.loginWith(map[string]interface{}{
+			"role": c.config.Role,
+			"jwt":  jwt,
+		})
+	default:
+		return nil, fmt.Errorf("unsupported vault auth method: %s", c.config.AuthMethod)
+	}
+}
+
+// loginWith writes credentials to the auth method's login endpoint and
+// uses the resulting client token
+func (c *VaultClient) loginWith(data map[string]interface{}) (*vault.Secret, error) {
+	path := fmt.Sprintf("auth/%s/login", c.authMount())
+	secret, err := c.client.Logical().Write(path, data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to login with %s: %w", c.authMethod(), err)
+	}
+	if secret == nil || secret.Auth == nil || secret.Auth.ClientToken == "" {
+		return nil, fmt.Errorf("no token returned from %s login", c.authMethod())
+	}
+
+	c.client.SetToken(secret.Auth.ClientToken)
+	return secret, nil
+}
+
+// lookupToken describes a pre-issued token as a lease so that it can be
+// renewed like a login result
+func (c *VaultClient) lookupToken() (*vault.Secret, error) {
+	secret, err := c.client.Auth().Token().LookupSelf()
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up vault token: %w", err)
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, nil
+	}
+
+	ttl, _ := secret.TokenTTL()
+	if ttl == 0 {
+		return nil, nil
+	}
+	renewable, _ := secret.TokenIsRenewable()
+
+	return &vault.Secret{
+		Auth: &vault.SecretAuth{
+			ClientToken:   c.client.Token(),
+			Renewable:     renewable,
+			LeaseDuration: int(ttl.Seconds()),
+		},
+	}, nil
+}
+
+// manageToken keeps the token alive until Close is called
+func (c *VaultClient) manageToken(auth *vault.Secret) {
+	defer close(c.done)
+
+	for {
+		if auth == nil {
+			// Token does not expire
+			<-c.stop
+			return
+		}
+
+		watcher, err := c.client.NewLifetimeWatcher(&vault.LifetimeWatcherInput{
+			Secret: auth,
+		})
+		if err != nil {
+			log.Printf("Failed to start vault token renewal: %v", err)
+			<-c.stop
+			return
+		}
+		go watcher.Start()
+
+		if stopped := c.watchToken(watcher); stopped {
+			return
+		}
+
+		if c.authMethod() == VaultAuthToken {
+			log.Println("Vault token expired and cannot be renewed, provide a new token")
+			<-c.stop
+			return
+		}
+
+		var ok bool
+		if auth, ok = c.relogin(); !ok {
+			return
+		}
+		log.Printf("Logged in to vault again using %s", c.authMethod())
+	}
+}
+
+// Backoff between attempts to log in again after the token expired
+const (
+	minLoginRetryDelay = time.Second
+	maxLoginRetryDelay = time.Minute
+)
+
+// relogin logs in again, retrying with exponential backoff while Vault is
+// unavailable. It reports false if the client was closed first.
+func (c *VaultClient) relogin() (*vault.Secret, bool) {
+	delay := minLoginRetryDelay
+	for {
+		auth, err := c.login()
+		if err == nil {
+			return auth, true
+		}
+		log.Printf("Failed to log in to vault again, retrying in %s: %v", delay, err)
+
+		select {
+		case <-c.stop:
+			return nil, false
+		case <-time.After(delay):
+		}
+		if delay *= 2; delay > maxLoginRetryDelay {
+			delay = maxLoginRetryDelay
+		}
+	}
+}
+
+// watchToken waits for the token lease to end and reports whether the
+// client was closed in the meantime
+func (c *VaultClient) watchToken(watcher *vault.LifetimeWatcher) bool {
+	defer watcher.Stop()
+
+	for {
+		select {
+		case <-c.stop:
+			return true
+		case err := <-watcher.DoneCh():
+			if err != nil {
+				log.Printf("Vault token renewal failed: %v", err)
+			}
+			return false
+		case <-watcher.RenewCh():
+		}
+	}
+}
+
+func (c *VaultClient) authMethod() string {
+	if c.config.AuthMethod == "" {
+		return VaultAuthToken
+	}
+	return c.config.AuthMethod
+}
+
+func (c *VaultClient) authMount() string {
+	if c.config.AuthMount != "" {
+		return c.config.AuthMount
+	}
+	return c.authMethod()
+}
+
+func readSecretFile(path string) (string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(content)), nil
+}
+
+// jsonInt64 converts a number from a Vault response to int64
+func jsonInt64(value interface{}) (int64, bool) {
+	switch v := value.(type) {
+	case json.Number:
+		n, err := v.Int64()
+		return n, err == nil
+	case float64:
+		return int64(v), true
+	default:
+		return 0, false
+	}
+}
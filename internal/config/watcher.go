@@ -0,0 +1,190 @@
+package config
+
+import (
+	"context"
+	"log"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Versioned is implemented by sources that can cheaply report whether their
+// content changed, e.g. a KV v2 secret version or a file modification time
+type Versioned interface {
+	Version() (int64, error)
+}
+
+// Change describes the difference between two configurations
+type Change struct {
+	Old  *Config
+	New  *Config
+	Keys []string
+}
+
+// Changed reports whether any key equal to or nested under one of the given
+// prefixes changed, e.g. Changed("cache") or Changed("database.url")
+func (c *Change) Changed(prefixes ...string) bool {
+	for _, key := range c.Keys {
+		for _, prefix := range prefixes {
+			if key == prefix || strings.HasPrefix(key, prefix+".") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Subscriber is notified after a reload produced a different configuration
+type Subscriber interface {
+	OnConfigChange(ctx context.Context, change *Change) error
+}
+
+// SubscriberFunc adapts a function to the Subscriber interface
+type SubscriberFunc func(ctx context.Context, change *Change) error
+
+// OnConfigChange calls f
+func (f SubscriberFunc) OnConfigChange(ctx context.Context, change *Change) error {
+	return f(ctx, change)
+}
+
+// Watcher periodically checks the versioned sources of a loader and, when
+// one of them changed, reloads the configuration and notifies subscribers
+type Watcher struct {
+	loader      *Loader
+	interval    time.Duration
+	mu          sync.RWMutex
+	current     *Config
+	versions    map[string]int64
+	subscribers []Subscriber
+}
+
+// NewWatcher creates a watcher for a configuration previously produced by
+// loader
+func NewWatcher(loader *Loader, current *Config, interval time.Duration) *Watcher {
+	return &Watcher{
+		loader:   loader,
+		interval: interval,
+		current:  current,
+		versions: loader.Versions(),
+	}
+}
+
+// Subscribe registers a subscriber for configuration changes
+func (w *Watcher) Subscribe(subscriber Subscriber) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.subscribers = append(w.subscribers, subscriber)
+}
+
+// Current returns the most recently loaded configuration
+func (w *Watcher) Current() *Config {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.current
+}
+
+// Run polls for changes until ctx is cancelled
+func (w *Watcher) Run(ctx context.Context) {
+	if w.interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := w.Check(ctx); err != nil {
+				log.Printf("Config reload failed: %v", err)
+			}
+		}
+	}
+}
+
+// Check reloads the configuration if any versioned source changed
+func (w *Watcher) Check(ctx context.Context) error {
+	versions := w.loader.Versions()
+	if reflect.DeepEqual(versions, w.versions) {
+		return nil
+	}
+	if err := w.Reload(ctx); err != nil {
+		return err
+	}
+	w.versions = versions
+	return nil
+}
+
+// Reload loads the configuration and notifies subscribers of any change.
+// Subscriber errors are logged and do not stop other subscribers.
+func (w *Watcher) Reload(ctx context.Context) error {
+	next, err := w.loader.Load()
+	if err != nil {
+		return err
+	}
+
+	w.mu.Lock()
+	change := &Change{
+		Old:  w.current,
+		New:  next,
+		Keys: Diff(w.current, next),
+	}
+	if len(change.Keys) == 0 {
+		w.mu.Unlock()
+		return nil
+	}
+	w.current = next
+	subscribers := append([]Subscriber(nil), w.subscribers...)
+	w.mu.Unlock()
+
+	log.Printf("Configuration changed: %s", strings.Join(change.Keys, ", "))
+	for _, subscriber := range subscribers {
+		if err := subscriber.OnConfigChange(ctx, change); err != nil {
+			log.Printf("Config subscriber failed: %v", err)
+		}
+	}
+	return nil
+}
+
+// Diff returns the sorted keys whose values differ between two configs
+func Diff(old, new *Config) []string {
+	oldValues := Values(old)
+	newValues := Values(new)
+
+	var keys []string
+	for key, value := range newValues {
+		if !reflect.DeepEqual(oldValues[key], value) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Values flattens target into a map of dotted keys to field values
+func Values(target interface{}) map[string]interface{} {
+	values := make(map[string]interface{})
+	collectValues(reflect.Indirect(reflect.ValueOf(target)), "", values)
+	return values
+}
+
+func collectValues(v reflect.Value, prefix string, values map[string]interface{}) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		key, ok := fieldKey(field)
+		if !ok {
+			continue
+		}
+		path := joinKey(prefix, key)
+		if isSection(field.Type) {
+			collectValues(v.Field(i), path, values)
+			continue
+		}
+		values[path] = v.Field(i).Interface()
+	}
+}
@@ -0,0 +1,91 @@
+package container
+
+import (
+	"fmt"
+	"io"
+	"time"
+)
+
+// BeanInfo describes a registered component
+type BeanInfo struct {
+	Name         string   `json:"name"`
+	Type         string   `json:"type"`
+	Aliases      []string `json:"aliases,omitempty"`
+	Scope        string   `json:"scope"`
+	Primary      bool     `json:"primary,omitempty"`
+	Dependencies []string `json:"dependencies"`
+	// InitializedAt and InitDuration are set for singletons built by the
+	// container. InitDuration excludes the time spent on dependencies.
+	InitializedAt *time.Time `json:"initialized_at,omitempty"`
+	InitDuration  string     `json:"init_duration,omitempty"`
+}
+
+// Beans lists the registered components in registration order. Components
+// whose conditions did not hold are not listed once the container is built.
+func (c *Container) Beans() []BeanInfo {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	beans := make([]BeanInfo, 0, len(c.defs))
+	for _, d := range c.defs {
+		bean := BeanInfo{
+			Name:         d.name,
+			Type:         d.typ.String(),
+			Scope:        d.scope.String(),
+			Primary:      d.primary,
+			Dependencies: c.dependencyNames(d),
+		}
+		for _, alias := range d.aliases {
+			bean.Aliases = append(bean.Aliases, alias.String())
+		}
+		if !d.initializedAt.IsZero() {
+			initializedAt := d.initializedAt
+			bean.InitializedAt = &initializedAt
+			bean.InitDuration = d.initDuration.String()
+		}
+		beans = append(beans, bean)
+	}
+	return beans
+}
+
+// dependencyNames returns the names of the components d depends on.
+// Dependencies that cannot be resolved are described by their type.
+func (c *Container) dependencyNames(d *definition) []string {
+	names := make([]string, len(d.params))
+	for i, dep := range d.params {
+		if next, err := c.lookup(dep); err == nil {
+			names[i] = next.name
+		} else {
+			names[i] = dep.String()
+		}
+	}
+	return names
+}
+
+// WriteDOT writes the dependency graph in Graphviz DOT format, with an edge
+// from every component to each of its dependencies
+func (c *Container) WriteDOT(w io.Writer) error {
+	beans := c.Beans()
+
+	if _, err := fmt.Fprintln(w, "digraph beans {\n\trankdir=LR;\n\tnode [shape=box];"); err != nil {
+		return err
+	}
+	for _, bean := range beans {
+		label := fmt.Sprintf("%s\n%s", bean.Name, bean.Scope)
+		if bean.Type != bean.Name {
+			label = fmt.Sprintf("%s\n%s, %s", bean.Name, bean.Type, bean.Scope)
+		}
+		if _, err := fmt.Fprintf(w, "\t%q [label=%q];\n", bean.Name, label); err != nil {
+			return err
+		}
+	}
+	for _, bean := range beans {
+		for _, dep := range bean.Dependencies {
+			if _, err := fmt.Fprintf(w, "\t%q -> %q;\n", bean.Name, dep); err != nil {
+				return err
+			}
+		}
+	}
+	_, err := fmt.Fprintln(w, "}")
+	return err
+}
@@ -0,0 +1,158 @@
+package container
+
+import (
+	"fmt"
+	"reflect"
+
+	"go-spring.com/internal/config"
+)
+
+// condition restricts when a component is registered. Conditions are
+// evaluated when the container is built. The property, value and profiles
+// they were declared with are kept for wiregen, which generates the same
+// checks as Go code.
+type condition struct {
+	// missing is set by ConditionalOnMissingBean and checked after all
+	// other conditions
+	missing reflect.Type
+	match   func(cfg *config.Config) (bool, error)
+
+	property string
+	value    string
+	profiles []string
+}
+
+// ConditionalOnProperty registers the component only when the configuration
+// property key, e.g. "cache.type", has the given value
+func ConditionalOnProperty(key, value string) ProvideOption {
+	return func(d *definition) {
+		d.conditions = append(d.conditions, condition{
+			property: key,
+			value:    value,
+			match: func(cfg *config.Config) (bool, error) {
+				actual, ok := config.Values(cfg)[key]
+				if !ok {
+					return false, fmt.Errorf("unknown configuration property %q in condition of %s", key, d.name)
+				}
+				return fmt.Sprint(actual) == value, nil
+			},
+		})
+	}
+}
+
+// ConditionalOnProfile registers the component only when the active profiles
+// match the expressions, as accepted by config.Config.AcceptsProfiles
+func ConditionalOnProfile(expressions ...string) ProvideOption {
+	return func(d *definition) {
+		d.conditions = append(d.conditions, condition{
+			profiles: expressions,
+			match: func(cfg *config.Config) (bool, error) {
+				return cfg.AcceptsProfiles(expressions...), nil
+			},
+		})
+	}
+}
+
+// ConditionalOnMissingBean registers the component only when no other
+// component provides the type iface points to, e.g.
+// ConditionalOnMissingBean(new(cache.Cache)). Defaults registered this way
+// are replaced by providing another component of that type.
+func ConditionalOnMissingBean(iface interface{}) ProvideOption {
+	return func(d *definition) {
+		d.conditions = append(d.conditions, condition{
+			missing: reflect.TypeOf(iface).Elem(),
+		})
+	}
+}
+
+// applyConditions removes the components whose conditions do not hold.
+// Components conditional on a missing bean are considered in registration
+// order, after all other conditions, so the first of several alternatives
+// wins.
+func (c *Container) applyConditions() error {
+	var cfg *config.Config
+	for _, d := range c.defs {
+		if d.built && d.typ == reflect.TypeOf(cfg) {
+			cfg = d.value.Interface().(*config.Config)
+		}
+	}
+
+	var active, pending []*definition
+	for _, d := range c.defs {
+		matched, err := d.matchConditions(cfg)
+		if err != nil {
+			return err
+		}
+		if !matched {
+			continue
+		}
+		if d.conditionalOnMissing() {
+			pending = append(pending, d)
+		} else {
+			active = append(active, d)
+		}
+	}
+
+	for _, d := range pending {
+		if !providedBy(active, d) {
+			active = append(active, d)
+		}
+	}
+
+	// Keep the registration order
+	enabled := make(map[*definition]bool, len(active))
+	for _, d := range active {
+		enabled[d] = true
+	}
+	defs := c.defs[:0]
+	for _, d := range c.defs {
+		if enabled[d] {
+			defs = append(defs, d)
+		}
+	}
+	c.defs = defs
+	return nil
+}
+
+// matchConditions evaluates the conditions of d other than
+// ConditionalOnMissingBean
+func (d *definition) matchConditions(cfg *config.Config) (bool, error) {
+	for _, cond := range d.conditions {
+		if cond.match == nil {
+			continue
+		}
+		if cfg == nil {
+			return false, fmt.Errorf("conditions of %s require a *config.Config component", d.name)
+		}
+		matched, err := cond.match(cfg)
+		if err != nil || !matched {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+func (d *definition) conditionalOnMissing() bool {
+	for _, cond := range d.conditions {
+		if cond.missing != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// providedBy reports whether one of defs provides a type d is conditional
+// on missing
+func providedBy(defs []*definition, d *definition) bool {
+	for _, cond := range d.conditions {
+		if cond.missing == nil {
+			continue
+		}
+		for _, other := range defs {
+			if other.provides(cond.missing) {
+				return true
+			}
+		}
+	}
+	return false
+}
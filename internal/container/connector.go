@@ -0,0 +1,155 @@
+package container
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"sync"
+)
+
+// rotatingConnector opens database connections with a DSN that can be
+// replaced at runtime. Connections opened with an older DSN report
+// themselves invalid, so database/sql retires them once they are returned
+// to the pool instead of interrupting queries that are still running.
+type rotatingConnector struct {
+	driver     driver.Driver
+	mu         sync.RWMutex
+	dsn        string
+	generation uint64
+}
+
+func newRotatingConnector(d driver.Driver, dsn string) *rotatingConnector {
+	return &rotatingConnector{
+		driver: d,
+		dsn:    dsn,
+	}
+}
+
+// Connect opens a connection using the current DSN
+func (c *rotatingConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	c.mu.RLock()
+	dsn, generation := c.dsn, c.generation
+	c.mu.RUnlock()
+
+	var conn driver.Conn
+	var err error
+	if dc, ok := c.driver.(driver.DriverContext); ok {
+		var connector driver.Connector
+		if connector, err = dc.OpenConnector(dsn); err != nil {
+			return nil, err
+		}
+		conn, err = connector.Connect(ctx)
+	} else {
+		conn, err = c.driver.Open(dsn)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &rotatingConn{Conn: conn, connector: c, generation: generation}, nil
+}
+
+// Driver returns the underlying driver
+func (c *rotatingConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+// Rotate switches new connections to dsn and invalidates existing ones
+func (c *rotatingConnector) Rotate(dsn string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.dsn = dsn
+	c.generation++
+}
+
+func (c *rotatingConnector) current(generation uint64) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.generation == generation
+}
+
+// openRotatingDB opens a *sql.DB whose DSN can be rotated with the returned
+// connector. The driver is looked up by name like sql.Open does.
+func openRotatingDB(driverName, dsn string) (*sql.DB, *rotatingConnector, error) {
+	probe, err := sql.Open(driverName, dsn)
+	if err != nil {
+		return nil, nil, err
+	}
+	d := probe.Driver()
+	probe.Close()
+
+	connector := newRotatingConnector(d, dsn)
+	return sql.OpenDB(connector), connector, nil
+}
+
+// rotatingConn forwards the optional driver interfaces database/sql relies
+// on, so wrapping does not change behaviour such as isolation levels
+type rotatingConn struct {
+	driver.Conn
+	connector  *rotatingConnector
+	generation uint64
+}
+
+// IsValid reports whether the connection was opened with the current DSN
+func (c *rotatingConn) IsValid() bool {
+	if v, ok := c.Conn.(driver.Validator); ok && !v.IsValid() {
+		return false
+	}
+	return c.connector.current(c.generation)
+}
+
+func (c *rotatingConn) ResetSession(ctx context.Context) error {
+	if !c.connector.current(c.generation) {
+		return driver.ErrBadConn
+	}
+	if r, ok := c.Conn.(driver.SessionResetter); ok {
+		return r.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *rotatingConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if b, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return b.BeginTx(ctx, opts)
+	}
+	if opts.Isolation != driver.IsolationLevel(sql.LevelDefault) || opts.ReadOnly {
+		return nil, errors.New("driver does not support transaction options")
+	}
+	return c.Conn.Begin()
+}
+
+func (c *rotatingConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	if p, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		return p.PrepareContext(ctx, query)
+	}
+	return c.Conn.Prepare(query)
+}
+
+func (c *rotatingConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if e, ok := c.Conn.(driver.ExecerContext); ok {
+		return e.ExecContext(ctx, query, args)
+	}
+	return nil, driver.ErrSkip
+}
+
+func (c *rotatingConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if q, ok := c.Conn.(driver.QueryerContext); ok {
+		return q.QueryContext(ctx, query, args)
+	}
+	return nil, driver.ErrSkip
+}
+
+func (c *rotatingConn) Ping(ctx context.Context) error {
+	if p, ok := c.Conn.(driver.Pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *rotatingConn) CheckNamedValue(nv *driver.NamedValue) error {
+	if n, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return n.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
@@ -1,111 +1,209 @@
 package container
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"log"
+	"sync"
+
+	_ "github.com/lib/pq" // PostgreSQL driver for database/sql
 
 	"go-spring.com/internal/cache"
 	"go-spring.com/internal/config"
-	"go-spring.com/internal/repository"
+	"go-spring.com/internal/event"
+	"go-spring.com/internal/handler"
+	"go-spring.com/internal/observability"
 	"go-spring.com/internal/service"
 )
 
+// Container holds the application's components. Components are registered
+// with Provide or Supply, resolved from each other by constructor parameter
+// types and built in dependency order by Build. Containers share no state,
+// so several can run side by side in one process.
 type Container struct {
-	config   *config.Config
-	db       *sql.DB
-	cache    cache.Cache
-	userRepo *repository.UserRepository
-	userSvc  *service.UserService
+	mu        sync.Mutex
+	defs      []*definition
+	built     bool
+	lifecycle *Lifecycle
 }
 
-var globalContainer *Container
+// Option configures a Container
+type Option func(*options)
 
-func NewContainer() (*Container, error) {
-	// Load configuration from Vault
-	cfg, err := config.LoadConfig()
-	if err != nil {
-		return nil, fmt.Errorf("failed to load config from Vault: %w", err)
+type options struct {
+	loader    *config.Loader
+	providers []Provider
+	overrides []Provider
+}
+
+// WithLoader sets the loader cfg was produced by. It is watched for
+// configuration changes and provides the Vault client for database
+// credentials. Without it the configuration is static.
+func WithLoader(loader *config.Loader) Option {
+	return func(o *options) {
+		o.loader = loader
 	}
+}
 
-	// Initialize database
-	db, err := initDB(cfg)
-	if err != nil {
-		return nil, fmt.Errorf("failed to initialize database: %w", err)
+// WithProviders registers additional components next to DefaultProviders.
+// Defaults registered with ConditionalOnMissingBean, such as the cache, are
+// replaced by providing a component of the same type.
+func WithProviders(providers ...Provider) Option {
+	return func(o *options) {
+		o.providers = append(o.providers, providers...)
 	}
+}
 
-	// Initialize cache
-	cache, err := initCache(cfg)
-	if err != nil {
-		return nil, fmt.Errorf("failed to initialize cache: %w", err)
+// WithOverrides registers components in place of the default or additional
+// components of the same type. A provider replaces every component that
+// provides its type or one of the types given with As, e.g. a fake
+// registered with As(new(repository.UserStore)) replaces the
+// UserRepository.
+func WithOverrides(providers ...Provider) Option {
+	return func(o *options) {
+		o.overrides = append(o.overrides, providers...)
 	}
+}
 
-	// Initialize repositories
-	userRepo := repository.NewUserRepository(db)
+// NewContainer wires all components from an already loaded configuration,
+// so configuration is read exactly once at startup. If cfg is nil, it is
+// loaded with the configured loader, or config.NewDefaultLoader if none.
+//
+//go:generate go run -tags wiregen ../../cmd/wiregen -o wire_gen.go
+func NewContainer(cfg *config.Config, opts ...Option) (*Container, error) {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
 
-	// Initialize services
-	userSvc := service.NewUserService(userRepo, cache)
+	loader := o.loader
+	if cfg == nil {
+		if loader == nil {
+			loader = config.NewDefaultLoader()
+		}
+		var err error
+		if cfg, err = loader.Load(); err != nil {
+			return nil, fmt.Errorf("failed to load config: %w", err)
+		}
+	}
+	if loader == nil {
+		// Static configuration without sources to watch
+		loader = config.NewLoader()
+	}
 
-	container := &Container{
-		config:   cfg,
-		db:       db,
-		cache:    cache,
-		userRepo: userRepo,
-		userSvc:  userSvc,
+	lifecycle := NewLifecycle()
+	// Stop the Vault token renewal after everything that uses the client
+	lifecycle.Append(Hook{
+		Name: "config loader",
+		OnStop: func(ctx context.Context) error {
+			return loader.Close()
+		},
+	})
+
+	var container *Container
+	var err error
+	if len(o.providers) == 0 && len(o.overrides) == 0 {
+		container, err = newContainer(cfg, loader, lifecycle)
+	} else {
+		container, err = buildContainer(cfg, loader, lifecycle, o.providers, o.overrides)
+	}
+	if err != nil {
+		// Release what was constructed before the failure
+		if stopErr := lifecycle.Stop(context.Background()); stopErr != nil {
+			log.Printf("Failed to stop components: %v", stopErr)
+		}
+		return nil, err
 	}
+	return container, nil
+}
 
-	globalContainer = container
+// newRootContainer returns a container holding the lifecycle, the
+// configuration and its loader, which every wiring starts from
+func newRootContainer(cfg *config.Config, loader *config.Loader, lc *Lifecycle) (*Container, error) {
+	container := &Container{lifecycle: lc}
+	for _, value := range []interface{}{lc, cfg, loader} {
+		if err := container.Supply(value); err != nil {
+			return nil, err
+		}
+	}
 	return container, nil
 }
 
-func GetContainer() *Container {
-	return globalContainer
+// buildContainer wires DefaultProviders and the additional providers by
+// reflection, with overrides in place of the components they replace. It
+// is used instead of the generated newContainer when the defaults are
+// extended.
+func buildContainer(cfg *config.Config, loader *config.Loader, lc *Lifecycle, providers, overrides []Provider) (*Container, error) {
+	container, err := newRootContainer(cfg, loader, lc)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range append(DefaultProviders(), providers...) {
+		if err := p.register(container); err != nil {
+			return nil, err
+		}
+	}
+	for _, p := range overrides {
+		if err := p.register(container, replacing()); err != nil {
+			return nil, err
+		}
+	}
+	if err := container.Build(); err != nil {
+		return nil, err
+	}
+	return container, nil
 }
 
 func (c *Container) GetConfig() *config.Config {
-	return c.config
+	return c.GetConfigWatcher().Current()
+}
+
+func (c *Container) GetConfigWatcher() *config.Watcher {
+	return MustGet[*config.Watcher](c)
 }
 
 func (c *Container) GetDB() *sql.DB {
-	return c.db
+	return MustGet[*sql.DB](c)
 }
 
 func (c *Container) GetCache() cache.Cache {
-	return c.cache
+	return MustGet[cache.Cache](c)
 }
 
 func (c *Container) GetUserService() *service.UserService {
-	return c.userSvc
+	return MustGet[*service.UserService](c)
 }
 
-func (c *Container) Close() error {
-	if err := c.db.Close(); err != nil {
-		return fmt.Errorf("failed to close database: %w", err)
-	}
-	return nil
+func (c *Container) GetHandler() *handler.Handler {
+	return MustGet[*handler.Handler](c)
 }
 
-func initDB(cfg *config.Config) (*sql.DB, error) {
+func (c *Container) GetTracer() *observability.Tracer {
+	return MustGet[*observability.Tracer](c)
+}
 
-	db, err := sql.Open("postgres", cfg.Database.URL)
-	if err != nil {
-		return nil, fmt.Errorf("failed to connect to database: %w", err)
-	}
+func (c *Container) GetEventBus() *event.Bus {
+	return MustGet[*event.Bus](c)
+}
 
-	return db, nil
-}
-
-func initCache(cfg *config.Config) (cache.Cache, error) {
-	switch cfg.Cache.Type {
-	case "memory":
-		return cache.NewMemoryCache(), nil
-	case "redis":
-		return cache.NewRedisCache(
-			cfg.Cache.Redis.Host,
-			cfg.Cache.Redis.Port,
-			cfg.Cache.Redis.Password,
-			cfg.Cache.Redis.DB,
-		)
-	default:
-		return nil, fmt.Errorf("unsupported cache type: %s", cfg.Cache.Type)
+// Start waits for the dependencies to pass their health checks, then runs
+// the OnStart hooks of the components in dependency order. It fails if a
+// required dependency is still unavailable after all retries.
+func (c *Container) Start(ctx context.Context) error {
+	if _, err := MustGet[*observability.HealthChecks](c).WaitReady(ctx); err != nil {
+		return fmt.Errorf("startup checks failed: %w", err)
 	}
+	return c.lifecycle.Start(ctx)
+}
+
+// Stop runs the OnStop hooks of the components in reverse dependency
+// order, each bounded by its timeout
+func (c *Container) Stop(ctx context.Context) error {
+	return c.lifecycle.Stop(ctx)
+}
+
+// Close stops the components without a deadline beyond the hook timeouts
+func (c *Container) Close() error {
+	return c.Stop(context.Background())
 }
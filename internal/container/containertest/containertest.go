@@ -0,0 +1,95 @@
+// Package containertest builds application containers for tests in which
+// any component can be replaced, so handlers can be exercised with httptest
+// without Vault, PostgreSQL or Redis.
+package containertest
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"go-spring.com/internal/config"
+	"go-spring.com/internal/container"
+	"go-spring.com/internal/server"
+)
+
+// Builder configures a test container
+type Builder struct {
+	cfg       *config.Config
+	overrides []container.Provider
+}
+
+// New returns a builder for a container with the default configuration
+// values and the test profile, which disables configuration reloading. The
+// cache is in memory and the database is never connected to unless a test
+// uses it, so tests usually override the user store.
+func New() *Builder {
+	cfg := &config.Config{}
+	if err := config.Bind(config.DefaultValues(), cfg); err != nil {
+		panic(err)
+	}
+	cfg.Database.URL = "postgres://localhost:5432/test?sslmode=disable"
+	cfg.Profiles = []string{"test"}
+	// There is no database to wait for
+	cfg.Startup.Attempts = 0
+	cfg.Startup.Optional = []string{"database", "cache"}
+
+	return &Builder{cfg: cfg}
+}
+
+// Configure changes the configuration before the container is built
+func (b *Builder) Configure(fn func(cfg *config.Config)) *Builder {
+	fn(b.cfg)
+	return b
+}
+
+// Override registers a constructor in place of the components of the same
+// type, or of the types given with container.As, e.g.
+//
+//	Override(repository.NewMemoryUserStore, container.As(new(repository.UserStore)))
+func (b *Builder) Override(constructor interface{}, opts ...container.ProvideOption) *Builder {
+	b.overrides = append(b.overrides, container.Provider{Constructor: constructor, Options: opts})
+	return b
+}
+
+// Supply registers value in place of the components of the same type, or
+// of the types given with container.As
+func (b *Builder) Supply(value interface{}, opts ...container.ProvideOption) *Builder {
+	b.overrides = append(b.overrides, container.Provider{Value: value, Options: opts})
+	return b
+}
+
+// Build creates and starts the container. It is stopped when the test
+// finishes, and any error fails the test.
+func (b *Builder) Build(t testing.TB) *container.Container {
+	t.Helper()
+
+	c, err := container.NewContainer(b.cfg, container.WithOverrides(b.overrides...))
+	if err != nil {
+		t.Fatalf("failed to build test container: %v", err)
+	}
+	if err := c.Start(context.Background()); err != nil {
+		t.Fatalf("failed to start test container: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := c.Stop(context.Background()); err != nil {
+			t.Errorf("failed to stop test container: %v", err)
+		}
+	})
+	return c
+}
+
+// Server builds the container and returns the application's server
+// without starting it. Its Handler and ManagementHandler serve the public
+// and management routes.
+func (b *Builder) Server(t testing.TB) *server.Server {
+	t.Helper()
+	return server.NewServer(b.Build(t))
+}
+
+// Handler builds the container and returns the application's public HTTP
+// handler with all routes and middleware
+func (b *Builder) Handler(t testing.TB) http.Handler {
+	t.Helper()
+	return b.Server(t).Handler()
+}
@@ -0,0 +1,240 @@
+package container
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"sync"
+	"time"
+
+	vault "github.com/hashicorp/vault/api"
+
+	"go-spring.com/internal/config"
+)
+
+// dbCredentials keeps database credentials from Vault's database secrets
+// engine alive. The lease is renewed in the background and, once it can no
+// longer be renewed, new credentials are requested and the connector is
+// rotated before the old lease expires.
+type dbCredentials struct {
+	vault     *config.VaultClient
+	mount     string
+	role      string
+	connector *rotatingConnector
+
+	mu       sync.Mutex
+	baseURL  string
+	username string
+	password string
+	leaseID  string
+	expires  time.Time
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// newDBCredentials requests the initial credentials and returns the DSN to
+// open the database with
+func newDBCredentials(client *config.VaultClient, cfg *config.DatabaseConfig) (*dbCredentials, string, *vault.Secret, error) {
+	m := &dbCredentials{
+		vault:   client,
+		mount:   cfg.CredentialsMount,
+		role:    cfg.CredentialsRole,
+		baseURL: cfg.URL,
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+
+	secret, err := m.fetch()
+	if err != nil {
+		return nil, "", nil, err
+	}
+	dsn, err := m.dsn()
+	if err != nil {
+		if revokeErr := m.revoke(); revokeErr != nil {
+			log.Printf("Failed to revoke unused database credentials: %v", revokeErr)
+		}
+		return nil, "", nil, err
+	}
+	return m, dsn, secret, nil
+}
+
+// start renews the lease of secret and rotates connections through
+// connector until Close is called
+func (m *dbCredentials) start(connector *rotatingConnector, secret *vault.Secret) {
+	m.connector = connector
+	go m.run(secret)
+}
+
+// SetURL changes the database URL the credentials are applied to
+func (m *dbCredentials) SetURL(rawURL string) error {
+	m.mu.Lock()
+	m.baseURL = rawURL
+	m.mu.Unlock()
+
+	dsn, err := m.dsn()
+	if err != nil {
+		return err
+	}
+	m.connector.Rotate(dsn)
+	return nil
+}
+
+// Close stops renewal and revokes the current lease
+func (m *dbCredentials) Close() error {
+	close(m.stop)
+	<-m.done
+	return m.revoke()
+}
+
+// revoke revokes the current lease
+func (m *dbCredentials) revoke() error {
+	m.mu.Lock()
+	leaseID := m.leaseID
+	m.mu.Unlock()
+
+	if leaseID == "" {
+		return nil
+	}
+	if err := m.vault.Client().Sys().Revoke(leaseID); err != nil {
+		return fmt.Errorf("failed to revoke database credentials: %w", err)
+	}
+	return nil
+}
+
+func (m *dbCredentials) run(secret *vault.Secret) {
+	defer close(m.done)
+
+	for {
+		watcher, err := m.vault.Client().NewLifetimeWatcher(&vault.LifetimeWatcherInput{
+			Secret: secret,
+		})
+		if err != nil {
+			log.Printf("Failed to start database credentials renewal: %v", err)
+			<-m.stop
+			return
+		}
+		go watcher.Start()
+
+		if stopped := m.watchLease(watcher); stopped {
+			return
+		}
+
+		// The lease is about to expire, switch to new credentials while
+		// connections opened with the old ones finish their work
+		var ok bool
+		if secret, ok = m.rotate(); !ok {
+			return
+		}
+		log.Printf("Database credentials rotated for role %s", m.role)
+	}
+}
+
+// Backoff between attempts to rotate the credentials
+const (
+	minRotateRetryDelay = time.Second
+	maxRotateRetryDelay = 30 * time.Second
+)
+
+// rotate requests new credentials and points new connections at them,
+// retrying with exponential backoff while Vault is unavailable. Retries
+// continue after the old lease expired, as new connections fail until
+// rotation succeeds. It reports false if Close was called first.
+func (m *dbCredentials) rotate() (*vault.Secret, bool) {
+	delay := minRotateRetryDelay
+	for {
+		secret, err := m.fetch()
+		if err == nil {
+			var dsn string
+			if dsn, err = m.dsn(); err == nil {
+				m.connector.Rotate(dsn)
+				return secret, true
+			}
+		}
+
+		m.mu.Lock()
+		expires := m.expires
+		m.mu.Unlock()
+		if time.Now().Before(expires) {
+			log.Printf("Failed to rotate database credentials, lease expires at %s, retrying in %s: %v", expires.Format(time.RFC3339), delay, err)
+		} else {
+			log.Printf("Failed to rotate database credentials, lease has expired, retrying in %s: %v", delay, err)
+		}
+
+		select {
+		case <-m.stop:
+			return nil, false
+		case <-time.After(delay):
+		}
+		if delay *= 2; delay > maxRotateRetryDelay {
+			delay = maxRotateRetryDelay
+		}
+	}
+}
+
+// watchLease waits for the lease to end and reports whether Close was
+// called in the meantime
+func (m *dbCredentials) watchLease(watcher *vault.LifetimeWatcher) bool {
+	defer watcher.Stop()
+
+	for {
+		select {
+		case <-m.stop:
+			return true
+		case err := <-watcher.DoneCh():
+			if err != nil {
+				log.Printf("Database credentials renewal failed: %v", err)
+			}
+			return false
+		case renewal := <-watcher.RenewCh():
+			m.setExpiry(renewal.Secret)
+		}
+	}
+}
+
+// fetch requests new credentials and makes them current
+func (m *dbCredentials) fetch() (*vault.Secret, error) {
+	secret, err := m.vault.DatabaseCredentials(m.mount, m.role)
+	if err != nil {
+		return nil, err
+	}
+
+	username, _ := secret.Data["username"].(string)
+	password, _ := secret.Data["password"].(string)
+	if username == "" {
+		return nil, fmt.Errorf("database credentials for role %s have no username", m.role)
+	}
+
+	m.mu.Lock()
+	m.username = username
+	m.password = password
+	m.leaseID = secret.LeaseID
+	m.mu.Unlock()
+	m.setExpiry(secret)
+
+	return secret, nil
+}
+
+// setExpiry records when the lease of secret, just issued or renewed,
+// expires
+func (m *dbCredentials) setExpiry(secret *vault.Secret) {
+	if secret == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.expires = time.Now().Add(time.Duration(secret.LeaseDuration) * time.Second)
+}
+
+// dsn returns the database URL with the current credentials
+func (m *dbCredentials) dsn() (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	u, err := url.Parse(m.baseURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid database URL: %w", err)
+	}
+	u.User = url.UserPassword(m.username, m.password)
+	return u.String(), nil
+}
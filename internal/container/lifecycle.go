@@ -0,0 +1,121 @@
+package container
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// DefaultHookTimeout bounds a lifecycle hook that does not set its own timeout
+const DefaultHookTimeout = 10 * time.Second
+
+// Hook is a pair of callbacks run when the application starts and stops.
+// Either callback may be nil.
+type Hook struct {
+	Name    string
+	OnStart func(ctx context.Context) error
+	OnStop  func(ctx context.Context) error
+	// Timeout bounds each callback, DefaultHookTimeout if zero
+	Timeout time.Duration
+}
+
+// Lifecycle runs the hooks of the container's components. Components
+// append their hooks from their constructors, which are called after those
+// of their dependencies, so hooks start in dependency order and stop in
+// reverse order.
+type Lifecycle struct {
+	mu      sync.Mutex
+	hooks   []*lifecycleHook
+	stopped bool
+}
+
+type lifecycleHook struct {
+	Hook
+	started bool
+}
+
+// NewLifecycle creates an empty lifecycle
+func NewLifecycle() *Lifecycle {
+	return &Lifecycle{}
+}
+
+// Append adds a hook to run after the hooks appended before it
+func (l *Lifecycle) Append(hook Hook) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.hooks = append(l.hooks, &lifecycleHook{Hook: hook})
+}
+
+// Start runs the OnStart callbacks in order. If one fails, the hooks
+// started so far are stopped again and its error is returned.
+func (l *Lifecycle) Start(ctx context.Context) error {
+	l.mu.Lock()
+	hooks := append([]*lifecycleHook(nil), l.hooks...)
+	l.mu.Unlock()
+
+	for _, h := range hooks {
+		if h.OnStart == nil || h.started {
+			continue
+		}
+		if err := runHook(ctx, h.Hook, h.OnStart); err != nil {
+			if stopErr := l.Stop(ctx); stopErr != nil {
+				log.Printf("Failed to stop components after failed start: %v", stopErr)
+			}
+			return fmt.Errorf("failed to start %s: %w", h.Name, err)
+		}
+		h.started = true
+	}
+	return nil
+}
+
+// Stop runs the OnStop callbacks in reverse order. Hooks whose OnStart
+// never ran are skipped. Every hook is run even if earlier ones fail, and
+// all errors are returned together. Stop only runs once.
+func (l *Lifecycle) Stop(ctx context.Context) error {
+	l.mu.Lock()
+	if l.stopped {
+		l.mu.Unlock()
+		return nil
+	}
+	l.stopped = true
+	hooks := append([]*lifecycleHook(nil), l.hooks...)
+	l.mu.Unlock()
+
+	var errs []error
+	for i := len(hooks) - 1; i >= 0; i-- {
+		h := hooks[i]
+		if h.OnStop == nil || (h.OnStart != nil && !h.started) {
+			continue
+		}
+		if err := runHook(ctx, h.Hook, h.OnStop); err != nil {
+			errs = append(errs, fmt.Errorf("failed to stop %s: %w", h.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// runHook calls fn with the hook's timeout. A callback that ignores its
+// context is abandoned once the timeout expires.
+func runHook(ctx context.Context, hook Hook, fn func(context.Context) error) error {
+	timeout := hook.Timeout
+	if timeout <= 0 {
+		timeout = DefaultHookTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- fn(ctx)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("timed out after %s: %w", timeout, ctx.Err())
+	}
+}
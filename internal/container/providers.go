@@ -0,0 +1,279 @@
+package container
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"log"
+
+	"go-spring.com/internal/cache"
+	"go-spring.com/internal/config"
+	"go-spring.com/internal/event"
+	"go-spring.com/internal/handler"
+	"go-spring.com/internal/observability"
+	"go-spring.com/internal/repository"
+	"go-spring.com/internal/service"
+)
+
+// DefaultProviders returns the providers for the application's components.
+// The configuration and its loader are supplied by NewContainer.
+func DefaultProviders() []Provider {
+	return []Provider{
+		{Constructor: newHealthChecks},
+		{Constructor: newTracer},
+		{Constructor: newEventBus},
+		{Constructor: newConfigWatcher},
+		{Constructor: newDBPool},
+		{Constructor: provideDB},
+		{Constructor: newMemoryCache, Options: []ProvideOption{
+			As(new(cache.Cache)),
+			ConditionalOnProperty("cache.type", "memory"),
+			ConditionalOnMissingBean(new(cache.Cache)),
+		}},
+		{Constructor: newRedisCache, Options: []ProvideOption{
+			As(new(cache.Cache)),
+			ConditionalOnProperty("cache.type", "redis"),
+			ConditionalOnMissingBean(new(cache.Cache)),
+		}},
+		{Constructor: repository.NewTransactionManager},
+		{Constructor: repository.NewUserRepository, Options: []ProvideOption{As(new(repository.UserStore))}},
+		{Constructor: service.NewUserService},
+		{Constructor: handler.NewHandler},
+		{Constructor: newEventBusDrain},
+		// Registered last so that reloads stop before the components they
+		// reconnect. Tests expect a fixed configuration.
+		{Constructor: newWatcherRunner, Options: []ProvideOption{
+			ConditionalOnProfile("!test"),
+		}},
+	}
+}
+
+func newHealthChecks(cfg *config.Config) *observability.HealthChecks {
+	return observability.NewHealthChecks(observability.CheckPolicy{
+		Attempts:     cfg.Startup.Attempts,
+		InitialDelay: cfg.Startup.InitialDelay,
+		MaxDelay:     cfg.Startup.MaxDelay,
+		Timeout:      cfg.Startup.Timeout,
+		Optional:     cfg.Startup.Optional,
+	})
+}
+
+// newTracer creates the application tracer. It uses the process-wide
+// tracer provider, which main shuts down after the containers are stopped.
+func newTracer() *observability.Tracer {
+	return observability.NewTracer("go-spring")
+}
+
+// newEventBus creates the application event bus. It is drained on
+// shutdown by eventBusDrain.
+func newEventBus() *event.Bus {
+	return event.NewBus()
+}
+
+// eventBusDrain waits for the asynchronous event listeners on shutdown
+type eventBusDrain struct {
+	bus *event.Bus
+}
+
+// newEventBusDrain is registered after the components the listeners use,
+// so they are still open while the bus drains
+func newEventBusDrain(lifecycle *Lifecycle, bus *event.Bus) *eventBusDrain {
+	lifecycle.Append(Hook{
+		Name:   "event bus",
+		OnStop: bus.Close,
+	})
+	return &eventBusDrain{bus: bus}
+}
+
+func newConfigWatcher(loader *config.Loader, cfg *config.Config) *config.Watcher {
+	return config.NewWatcher(loader, cfg, cfg.Reload.Interval)
+}
+
+// watcherRunner polls the configuration sources while the application runs
+// and reconnects components in place when they change
+type watcherRunner struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func newWatcherRunner(lifecycle *Lifecycle, watcher *config.Watcher) *watcherRunner {
+	r := &watcherRunner{done: make(chan struct{})}
+	lifecycle.Append(Hook{
+		Name: "config watcher",
+		OnStart: func(ctx context.Context) error {
+			var runCtx context.Context
+			runCtx, r.cancel = context.WithCancel(context.Background())
+			go func() {
+				defer close(r.done)
+				watcher.Run(runCtx)
+			}()
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			r.cancel()
+			<-r.done
+			return nil
+		},
+	})
+	return r
+}
+
+// dbPool owns the database handle and what is needed to rotate its
+// connections when the URL or the credentials change
+type dbPool struct {
+	db        *sql.DB
+	connector *rotatingConnector
+	creds     *dbCredentials
+}
+
+func newDBPool(lifecycle *Lifecycle, checks *observability.HealthChecks, cfg *config.Config, loader *config.Loader, watcher *config.Watcher) (*dbPool, error) {
+	pool := &dbPool{}
+	if cfg.Database.CredentialsRole == "" {
+		db, connector, err := openRotatingDB("postgres", cfg.Database.URL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to database: %w", err)
+		}
+		pool.db, pool.connector = db, connector
+	} else {
+		// Use short-lived credentials from Vault's database secrets engine
+		vaultClient, err := loader.VaultClient()
+		if err != nil {
+			return nil, fmt.Errorf("database credentials role requires vault: %w", err)
+		}
+		creds, dsn, secret, err := newDBCredentials(vaultClient, &cfg.Database)
+		if err != nil {
+			return nil, err
+		}
+
+		db, connector, err := openRotatingDB("postgres", dsn)
+		if err != nil {
+			if revokeErr := creds.revoke(); revokeErr != nil {
+				log.Printf("Failed to revoke unused database credentials: %v", revokeErr)
+			}
+			return nil, fmt.Errorf("failed to connect to database: %w", err)
+		}
+		creds.start(connector, secret)
+		pool.db, pool.connector, pool.creds = db, connector, creds
+	}
+
+	checks.Register("database", pool.db.PingContext)
+	watcher.Subscribe(config.SubscriberFunc(pool.reload))
+	lifecycle.Append(Hook{
+		Name: "database",
+		OnStop: func(ctx context.Context) error {
+			return pool.Close()
+		},
+	})
+	return pool, nil
+}
+
+func provideDB(pool *dbPool) *sql.DB {
+	return pool.db
+}
+
+// reload points new database connections at a changed database URL
+func (p *dbPool) reload(ctx context.Context, change *config.Change) error {
+	if !change.Changed("database.url") {
+		return nil
+	}
+	if p.creds != nil {
+		if err := p.creds.SetURL(change.New.Database.URL); err != nil {
+			return err
+		}
+	} else {
+		p.connector.Rotate(change.New.Database.URL)
+	}
+	log.Println("Database connections rotated to new configuration")
+	return nil
+}
+
+// Close closes the database and revokes dynamic credentials
+func (p *dbPool) Close() error {
+	if err := p.db.Close(); err != nil {
+		return fmt.Errorf("failed to close database: %w", err)
+	}
+	if p.creds != nil {
+		return p.creds.Close()
+	}
+	return nil
+}
+
+func newMemoryCache(watcher *config.Watcher) *cache.MemoryCache {
+	watcher.Subscribe(config.SubscriberFunc(warnCacheTypeChange))
+	return cache.NewMemoryCache()
+}
+
+// newRedisCache connects to Redis and reconnects when the Redis settings
+// change. A new connection is only swapped in once it answers a ping.
+func newRedisCache(lifecycle *Lifecycle, checks *observability.HealthChecks, cfg *config.Config, watcher *config.Watcher) (*cache.ReloadableCache, error) {
+	redis, err := openRedisCache(cfg)
+	if err != nil {
+		return nil, err
+	}
+	reloadable := cache.NewReloadableCache(redis)
+	checks.Register("cache", func(ctx context.Context) error {
+		return pingCache(ctx, reloadable.Current())
+	})
+
+	watcher.Subscribe(config.SubscriberFunc(warnCacheTypeChange))
+	watcher.Subscribe(config.SubscriberFunc(func(ctx context.Context, change *config.Change) error {
+		if !change.Changed("cache.redis") || change.New.Cache.Type != "redis" {
+			return nil
+		}
+		next, err := openRedisCache(change.New)
+		if err != nil {
+			return fmt.Errorf("failed to reconnect cache: %w", err)
+		}
+		if err := next.Ping(ctx); err != nil {
+			next.Close()
+			return fmt.Errorf("failed to reconnect cache: %w", err)
+		}
+		if err := closeCache(reloadable.Swap(next)); err != nil {
+			log.Printf("Failed to close previous cache: %v", err)
+		}
+		log.Println("Cache reconnected with new configuration")
+		return nil
+	}))
+	lifecycle.Append(Hook{
+		Name: "cache",
+		OnStop: func(ctx context.Context) error {
+			return reloadable.Close()
+		},
+	})
+
+	return reloadable, nil
+}
+
+func openRedisCache(cfg *config.Config) (*cache.RedisCache, error) {
+	return cache.NewRedisCache(
+		cfg.Cache.Redis.Host,
+		cfg.Cache.Redis.Port,
+		cfg.Cache.Redis.Password,
+		cfg.Cache.Redis.DB,
+	)
+}
+
+// warnCacheTypeChange reports a cache type change, which selects another
+// component and therefore only applies after a restart
+func warnCacheTypeChange(ctx context.Context, change *config.Change) error {
+	if change.Changed("cache.type") {
+		log.Printf("Cache type changed to %s, restart required to apply", change.New.Cache.Type)
+	}
+	return nil
+}
+
+// pingCache checks the connection of caches backed by a server
+func pingCache(ctx context.Context, c cache.Cache) error {
+	if pinger, ok := c.(interface{ Ping(context.Context) error }); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
+
+func closeCache(c cache.Cache) error {
+	if closer, ok := c.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
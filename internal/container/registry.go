@@ -0,0 +1,542 @@
+package container
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// Provider pairs a component constructor with its registration options.
+// When Constructor is nil, Value is registered as is.
+type Provider struct {
+	Constructor interface{}
+	Value       interface{}
+	Options     []ProvideOption
+}
+
+// register adds the provider to c
+func (p Provider) register(c *Container, opts ...ProvideOption) error {
+	opts = append(append([]ProvideOption(nil), p.Options...), opts...)
+	if p.Constructor == nil {
+		return c.Supply(p.Value, opts...)
+	}
+	return c.Provide(p.Constructor, opts...)
+}
+
+// ProvideOption configures how a component is registered
+type ProvideOption func(*definition)
+
+// Name registers the component under name instead of its type name
+func Name(name string) ProvideOption {
+	return func(d *definition) {
+		d.name = name
+	}
+}
+
+// As additionally registers the component under an interface it
+// implements, given as a pointer to the interface, e.g. As(new(cache.Cache))
+func As(iface interface{}) ProvideOption {
+	return func(d *definition) {
+		d.aliases = append(d.aliases, reflect.TypeOf(iface).Elem())
+	}
+}
+
+// Primary marks the component as the one to inject when several components
+// are registered for the same type
+func Primary() ProvideOption {
+	return func(d *definition) {
+		d.primary = true
+	}
+}
+
+// Inject resolves the constructor parameters by component name, in order.
+// An empty name resolves that parameter by type.
+func Inject(names ...string) ProvideOption {
+	return func(d *definition) {
+		for i, name := range names {
+			if i < len(d.params) {
+				d.params[i].name = name
+			}
+		}
+	}
+}
+
+// WithScope sets the scope of the component, Singleton by default
+func WithScope(scope Scope) ProvideOption {
+	return func(d *definition) {
+		d.scope = scope
+	}
+}
+
+// replacing registers the component in place of the components already
+// registered for its type or any of its aliases
+func replacing() ProvideOption {
+	return func(d *definition) {
+		d.replaces = true
+	}
+}
+
+// definition describes a registered component
+type definition struct {
+	name       string
+	typ        reflect.Type
+	aliases    []reflect.Type
+	primary    bool
+	replaces   bool
+	scope      Scope
+	conditions []condition
+	ctor       reflect.Value
+	params     []dependency
+	value      reflect.Value
+	built      bool
+
+	initializedAt time.Time
+	initDuration  time.Duration
+}
+
+// dependency is a constructor parameter resolved by type or by name
+type dependency struct {
+	typ  reflect.Type
+	name string
+}
+
+func (d dependency) String() string {
+	if d.name != "" {
+		return fmt.Sprintf("%q (%s)", d.name, d.typ)
+	}
+	return d.typ.String()
+}
+
+// provides reports whether the component can be injected as t
+func (d *definition) provides(t reflect.Type) bool {
+	if d.typ == t {
+		return true
+	}
+	for _, alias := range d.aliases {
+		if alias == t {
+			return true
+		}
+	}
+	return false
+}
+
+// GraphError lists every problem found while validating the dependency graph
+type GraphError struct {
+	Problems []string
+}
+
+func (e *GraphError) Error() string {
+	return fmt.Sprintf("invalid dependency graph (%d problems): %s", len(e.Problems), strings.Join(e.Problems, "; "))
+}
+
+// Provide registers a constructor. Its parameters are resolved from other
+// components by type, and it must return the component, optionally followed
+// by an error.
+func (c *Container) Provide(constructor interface{}, opts ...ProvideOption) error {
+	d, err := newDefinition(constructor)
+	if err != nil {
+		return err
+	}
+	return c.register(d, opts)
+}
+
+// newDefinition describes the component built by constructor
+func newDefinition(constructor interface{}) (*definition, error) {
+	fn := reflect.ValueOf(constructor)
+	if !fn.IsValid() {
+		return nil, fmt.Errorf("provider must be a function, got nil")
+	}
+	t := fn.Type()
+	if t.Kind() != reflect.Func {
+		return nil, fmt.Errorf("provider must be a function, got %s", t)
+	}
+	if t.IsVariadic() {
+		return nil, fmt.Errorf("provider %s must not be variadic", t)
+	}
+	if t.NumOut() == 0 || t.NumOut() > 2 || (t.NumOut() == 2 && t.Out(1) != errorType) {
+		return nil, fmt.Errorf("provider %s must return a component and optionally an error", t)
+	}
+
+	d := &definition{
+		typ:  t.Out(0),
+		ctor: fn,
+	}
+	for i := 0; i < t.NumIn(); i++ {
+		d.params = append(d.params, dependency{typ: t.In(i)})
+	}
+	return d, nil
+}
+
+// supplyBuilt registers value, which the generated wiring constructed with
+// constructor at start, as a singleton. The constructor is not called; its
+// parameters are the dependencies listed by Beans.
+func (c *Container) supplyBuilt(constructor, value interface{}, start time.Time, opts ...ProvideOption) error {
+	d, err := newDefinition(constructor)
+	if err != nil {
+		return err
+	}
+	d.value = reflect.New(d.typ).Elem()
+	if value != nil {
+		d.value.Set(reflect.ValueOf(value))
+	}
+	d.built = true
+	d.initializedAt = start
+	d.initDuration = time.Since(start)
+	if err := configure(d, opts); err != nil {
+		return err
+	}
+	return c.add(d)
+}
+
+// Supply registers an already constructed value as a singleton component
+func (c *Container) Supply(value interface{}, opts ...ProvideOption) error {
+	v := reflect.ValueOf(value)
+	if !v.IsValid() {
+		return fmt.Errorf("cannot supply nil")
+	}
+	d := &definition{
+		typ:   v.Type(),
+		value: v,
+		built: true,
+	}
+	if err := configure(d, opts); err != nil {
+		return err
+	}
+	if d.scope != Singleton {
+		return fmt.Errorf("supplied component %s must be a singleton", d.name)
+	}
+	return c.add(d)
+}
+
+func (c *Container) register(d *definition, opts []ProvideOption) error {
+	if err := configure(d, opts); err != nil {
+		return err
+	}
+	return c.add(d)
+}
+
+// configure applies the options to d and checks its aliases
+func configure(d *definition, opts []ProvideOption) error {
+	for _, opt := range opts {
+		opt(d)
+	}
+	if d.name == "" {
+		d.name = d.typ.String()
+	}
+	for _, alias := range d.aliases {
+		if !d.typ.Implements(alias) {
+			return fmt.Errorf("%s does not implement %s", d.typ, alias)
+		}
+	}
+	return nil
+}
+
+// add registers the configured definition d
+func (c *Container) add(d *definition) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.built {
+		return fmt.Errorf("cannot register %s after the container was built", d.name)
+	}
+	if d.replaces {
+		c.removeReplacedBy(d)
+	}
+	for _, existing := range c.defs {
+		if existing.name == d.name {
+			return fmt.Errorf("component %s is already registered", d.name)
+		}
+	}
+	c.defs = append(c.defs, d)
+	return nil
+}
+
+// removeReplacedBy removes the components d is registered in place of
+func (c *Container) removeReplacedBy(d *definition) {
+	types := append([]reflect.Type{d.typ}, d.aliases...)
+	defs := c.defs[:0]
+	for _, existing := range c.defs {
+		replaced := false
+		for _, t := range types {
+			if existing.provides(t) {
+				replaced = true
+				break
+			}
+		}
+		if !replaced || existing.replaces {
+			defs = append(defs, existing)
+		}
+	}
+	c.defs = defs
+}
+
+// Build drops the components whose conditions do not hold, validates the
+// dependency graph, reporting every missing provider, ambiguous dependency,
+// scope violation and cycle at once, and then constructs all singletons in
+// dependency order
+func (c *Container) Build() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.applyConditions(); err != nil {
+		return err
+	}
+	if err := c.validate(); err != nil {
+		return err
+	}
+	for _, d := range c.defs {
+		if d.scope != Singleton {
+			continue
+		}
+		if _, err := c.instantiate(d, nil); err != nil {
+			return err
+		}
+	}
+	c.built = true
+	return nil
+}
+
+// validate checks that every dependency can be resolved and that there are
+// no cycles
+func (c *Container) validate() error {
+	var problems []string
+	for _, d := range c.defs {
+		for _, dep := range d.params {
+			next, err := c.lookup(dep)
+			if err != nil {
+				problems = append(problems, fmt.Sprintf("%s (required by %s)", err, d.name))
+				continue
+			}
+			// A longer-lived component cannot hold a request-scoped one
+			if next.scope == Request && d.scope != Request {
+				problems = append(problems, fmt.Sprintf("%s component %s depends on request-scoped %s", d.scope, d.name, next.name))
+			}
+		}
+	}
+
+	// Depth-first search for cycles over the resolvable edges
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make(map[*definition]int)
+	var path []*definition
+	var visit func(d *definition)
+	visit = func(d *definition) {
+		switch state[d] {
+		case visited:
+			return
+		case visiting:
+			var names []string
+			for i := len(path) - 1; i >= 0; i-- {
+				names = append([]string{path[i].name}, names...)
+				if path[i] == d {
+					break
+				}
+			}
+			problems = append(problems, "dependency cycle: "+strings.Join(append(names, d.name), " -> "))
+			return
+		}
+		state[d] = visiting
+		path = append(path, d)
+		for _, dep := range d.params {
+			if next, err := c.lookup(dep); err == nil {
+				visit(next)
+			}
+		}
+		path = path[:len(path)-1]
+		state[d] = visited
+	}
+	for _, d := range c.defs {
+		visit(d)
+	}
+
+	if len(problems) > 0 {
+		return &GraphError{Problems: problems}
+	}
+	return nil
+}
+
+// lookup finds the definition satisfying a dependency
+func (c *Container) lookup(dep dependency) (*definition, error) {
+	if dep.name != "" {
+		for _, d := range c.defs {
+			if d.name == dep.name {
+				if !d.provides(dep.typ) {
+					return nil, fmt.Errorf("component %q is %s, not %s", dep.name, d.typ, dep.typ)
+				}
+				return d, nil
+			}
+		}
+		return nil, fmt.Errorf("no component named %q", dep.name)
+	}
+
+	var candidates []*definition
+	for _, d := range c.defs {
+		if d.provides(dep.typ) {
+			candidates = append(candidates, d)
+		}
+	}
+	switch len(candidates) {
+	case 0:
+		return nil, fmt.Errorf("no provider for %s", dep.typ)
+	case 1:
+		return candidates[0], nil
+	}
+
+	var primary *definition
+	names := make([]string, len(candidates))
+	for i, d := range candidates {
+		names[i] = d.name
+		if d.primary {
+			if primary != nil {
+				return nil, fmt.Errorf("multiple primary providers for %s: %s", dep.typ, strings.Join(names, ", "))
+			}
+			primary = d
+		}
+	}
+	if primary == nil {
+		return nil, fmt.Errorf("multiple providers for %s: %s", dep.typ, strings.Join(names, ", "))
+	}
+	return primary, nil
+}
+
+// instantiate returns the component of d according to its scope,
+// constructing it and its dependencies first if needed. Request-scoped
+// components are kept in scope, which must be locked by the caller.
+func (c *Container) instantiate(d *definition, scope *requestScope) (reflect.Value, error) {
+	switch d.scope {
+	case Singleton:
+		if d.built {
+			return d.value, nil
+		}
+	case Request:
+		if scope == nil {
+			return reflect.Value{}, fmt.Errorf("request-scoped component %s resolved outside of a request", d.name)
+		}
+		if scope.closed {
+			return reflect.Value{}, fmt.Errorf("cannot resolve request-scoped component %s: request scope already closed", d.name)
+		}
+		if value, ok := scope.instances[d]; ok {
+			return value, nil
+		}
+	}
+
+	args := make([]reflect.Value, len(d.params))
+	for i, dep := range d.params {
+		next, err := c.lookup(dep)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		if args[i], err = c.instantiate(next, scope); err != nil {
+			return reflect.Value{}, err
+		}
+	}
+
+	start := time.Now()
+	value, err := d.call(args)
+	if err != nil {
+		return reflect.Value{}, fmt.Errorf("failed to initialize %s: %w", d.name, err)
+	}
+
+	switch d.scope {
+	case Singleton:
+		d.value = value
+		d.built = true
+		d.initializedAt = start
+		d.initDuration = time.Since(start)
+	case Request:
+		scope.add(d, value)
+	}
+	return value, nil
+}
+
+// call invokes the constructor of d
+func (d *definition) call(args []reflect.Value) (reflect.Value, error) {
+	results := d.ctor.Call(args)
+	if len(results) == 2 && !results[1].IsNil() {
+		return reflect.Value{}, results[1].Interface().(error)
+	}
+	return results[0], nil
+}
+
+// Resolve stores the component registered for the type target points to
+// in target
+func (c *Container) Resolve(target interface{}) error {
+	return c.resolve(context.Background(), dependency{}, target)
+}
+
+// ResolveContext is like Resolve but also resolves request-scoped
+// components from the request scope in ctx
+func (c *Container) ResolveContext(ctx context.Context, target interface{}) error {
+	return c.resolve(ctx, dependency{}, target)
+}
+
+// ResolveNamed stores the component registered under name in target
+func (c *Container) ResolveNamed(name string, target interface{}) error {
+	return c.resolve(context.Background(), dependency{name: name}, target)
+}
+
+func (c *Container) resolve(ctx context.Context, dep dependency, target interface{}) error {
+	v := reflect.ValueOf(target)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return fmt.Errorf("resolve target must be a non-nil pointer, got %T", target)
+	}
+	dep.typ = v.Type().Elem()
+
+	// Definitions and singletons no longer change once the container is
+	// built, so only request scopes need locking from here on
+	c.mu.Lock()
+	built := c.built
+	c.mu.Unlock()
+	if !built {
+		return fmt.Errorf("container is not built")
+	}
+
+	d, err := c.lookup(dep)
+	if err != nil {
+		return err
+	}
+
+	scope := scopeFromContext(ctx)
+	if scope != nil {
+		scope.mu.Lock()
+		defer scope.mu.Unlock()
+	}
+	value, err := c.instantiate(d, scope)
+	if err != nil {
+		return err
+	}
+	v.Elem().Set(value)
+	return nil
+}
+
+// Get returns the component registered for type T
+func Get[T any](c *Container) (T, error) {
+	var component T
+	err := c.Resolve(&component)
+	return component, err
+}
+
+// GetContext returns the component registered for type T, resolving
+// request-scoped components from the request scope in ctx
+func GetContext[T any](ctx context.Context, c *Container) (T, error) {
+	var component T
+	err := c.ResolveContext(ctx, &component)
+	return component, err
+}
+
+// MustGet returns the component registered for type T and panics if it
+// cannot be resolved. It is meant for components that are always present.
+func MustGet[T any](c *Container) T {
+	component, err := Get[T](c)
+	if err != nil {
+		panic(err)
+	}
+	return component
+}
@@ -0,0 +1,304 @@
+package container
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+type (
+	testConfig  struct{ name string }
+	testRepo    struct{ cfg *testConfig }
+	testService struct{ repo *testRepo }
+
+	testCycleA struct{}
+	testCycleB struct{}
+	testCycleC struct{}
+
+	testGreeter interface{ Greet() string }
+	testEnglish struct{}
+	testFrench  struct{}
+)
+
+func (testEnglish) Greet() string { return "hello" }
+func (testFrench) Greet() string  { return "bonjour" }
+
+func newTestConfig() *testConfig                        { return &testConfig{name: "default"} }
+func newTestRepo(cfg *testConfig) *testRepo             { return &testRepo{cfg: cfg} }
+func newTestService(repo *testRepo) *testService        { return &testService{repo: repo} }
+func newTestCycleA(*testCycleB) *testCycleA             { return &testCycleA{} }
+func newTestCycleB(*testCycleC) *testCycleB             { return &testCycleB{} }
+func newTestCycleC(*testCycleA) *testCycleC             { return &testCycleC{} }
+func newTestEnglish() *testEnglish                      { return &testEnglish{} }
+func newTestFrench() *testFrench                        { return &testFrench{} }
+func newTestGreeting(g testGreeter) string              { return g.Greet() }
+func newTestFailing() (*testConfig, error)              { return nil, errors.New("boom") }
+func newTestOtherConfig() *testConfig                   { return &testConfig{name: "other"} }
+func newTestSelfCycle(*testCycleA) *testCycleA          { return &testCycleA{} }
+func newTestCheckedRepo(*testConfig) (*testRepo, error) { return &testRepo{}, nil }
+
+func TestBuildReportsGraphProblems(t *testing.T) {
+	tests := []struct {
+		name      string
+		register  func(c *Container) error
+		wantParts []string
+	}{
+		{
+			name: "missing provider",
+			register: func(c *Container) error {
+				return c.Provide(newTestService)
+			},
+			wantParts: []string{"no provider for *container.testRepo (required by *container.testService)"},
+		},
+		{
+			name: "every missing provider at once",
+			register: func(c *Container) error {
+				if err := c.Provide(newTestService); err != nil {
+					return err
+				}
+				return c.Provide(newTestGreeting)
+			},
+			wantParts: []string{
+				"(2 problems)",
+				"no provider for *container.testRepo (required by *container.testService)",
+				"no provider for container.testGreeter (required by string)",
+			},
+		},
+		{
+			name: "ambiguous interface",
+			register: func(c *Container) error {
+				if err := c.Provide(newTestEnglish, As(new(testGreeter))); err != nil {
+					return err
+				}
+				if err := c.Provide(newTestFrench, As(new(testGreeter))); err != nil {
+					return err
+				}
+				return c.Provide(newTestGreeting)
+			},
+			wantParts: []string{"multiple providers for container.testGreeter: *container.testEnglish, *container.testFrench"},
+		},
+		{
+			name: "several primaries",
+			register: func(c *Container) error {
+				if err := c.Provide(newTestEnglish, As(new(testGreeter)), Primary()); err != nil {
+					return err
+				}
+				if err := c.Provide(newTestFrench, As(new(testGreeter)), Primary()); err != nil {
+					return err
+				}
+				return c.Provide(newTestGreeting)
+			},
+			wantParts: []string{"multiple primary providers for container.testGreeter"},
+		},
+		{
+			name: "cycle",
+			register: func(c *Container) error {
+				for _, ctor := range []interface{}{newTestCycleA, newTestCycleB, newTestCycleC} {
+					if err := c.Provide(ctor); err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+			wantParts: []string{"dependency cycle: *container.testCycleA -> *container.testCycleB -> *container.testCycleC -> *container.testCycleA"},
+		},
+		{
+			name: "self cycle",
+			register: func(c *Container) error {
+				return c.Provide(newTestSelfCycle)
+			},
+			wantParts: []string{"dependency cycle: *container.testCycleA -> *container.testCycleA"},
+		},
+		{
+			name: "unknown name",
+			register: func(c *Container) error {
+				if err := c.Provide(newTestConfig); err != nil {
+					return err
+				}
+				return c.Provide(newTestRepo, Inject("primary-config"))
+			},
+			wantParts: []string{`no component named "primary-config" (required by *container.testRepo)`},
+		},
+		{
+			name: "name of another type",
+			register: func(c *Container) error {
+				if err := c.Provide(newTestEnglish, Name("english")); err != nil {
+					return err
+				}
+				return c.Provide(newTestRepo, Inject("english"))
+			},
+			wantParts: []string{`component "english" is *container.testEnglish, not *container.testConfig`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Container{}
+			if err := tt.register(c); err != nil {
+				t.Fatalf("register: %v", err)
+			}
+			err := c.Build()
+			var graphErr *GraphError
+			if !errors.As(err, &graphErr) {
+				t.Fatalf("Build: got %v, want *GraphError", err)
+			}
+			for _, part := range tt.wantParts {
+				if !strings.Contains(err.Error(), part) {
+					t.Errorf("Build error %q does not contain %q", err, part)
+				}
+			}
+		})
+	}
+}
+
+func TestBuildResolvesDependencies(t *testing.T) {
+	tests := []struct {
+		name     string
+		register func(c *Container) error
+		check    func(t *testing.T, c *Container)
+	}{
+		{
+			name: "by type in dependency order",
+			register: func(c *Container) error {
+				// Registered before its dependencies
+				for _, ctor := range []interface{}{newTestService, newTestRepo, newTestConfig} {
+					if err := c.Provide(ctor); err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+			check: func(t *testing.T, c *Container) {
+				svc := MustGet[*testService](c)
+				if svc.repo != MustGet[*testRepo](c) || svc.repo.cfg != MustGet[*testConfig](c) {
+					t.Error("components are not shared singletons")
+				}
+			},
+		},
+		{
+			name: "primary among several",
+			register: func(c *Container) error {
+				if err := c.Provide(newTestEnglish, As(new(testGreeter))); err != nil {
+					return err
+				}
+				if err := c.Provide(newTestFrench, As(new(testGreeter)), Primary()); err != nil {
+					return err
+				}
+				return c.Provide(newTestGreeting)
+			},
+			check: func(t *testing.T, c *Container) {
+				if got := MustGet[string](c); got != "bonjour" {
+					t.Errorf("greeting: got %q, want bonjour", got)
+				}
+			},
+		},
+		{
+			name: "by name",
+			register: func(c *Container) error {
+				if err := c.Provide(newTestConfig, Primary()); err != nil {
+					return err
+				}
+				if err := c.Provide(newTestOtherConfig, Name("other")); err != nil {
+					return err
+				}
+				return c.Provide(newTestRepo, Inject("other"))
+			},
+			check: func(t *testing.T, c *Container) {
+				if got := MustGet[*testRepo](c).cfg.name; got != "other" {
+					t.Errorf("injected config: got %q, want other", got)
+				}
+				var cfg *testConfig
+				if err := c.ResolveNamed("other", &cfg); err != nil || cfg.name != "other" {
+					t.Errorf("ResolveNamed: got %v, %v", cfg, err)
+				}
+			},
+		},
+		{
+			name: "supplied value",
+			register: func(c *Container) error {
+				if err := c.Supply(&testConfig{name: "supplied"}); err != nil {
+					return err
+				}
+				return c.Provide(newTestCheckedRepo)
+			},
+			check: func(t *testing.T, c *Container) {
+				if got := MustGet[*testConfig](c).name; got != "supplied" {
+					t.Errorf("config: got %q, want supplied", got)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Container{}
+			if err := tt.register(c); err != nil {
+				t.Fatalf("register: %v", err)
+			}
+			if err := c.Build(); err != nil {
+				t.Fatalf("Build: %v", err)
+			}
+			tt.check(t, c)
+		})
+	}
+}
+
+func TestProvideRejectsInvalidConstructors(t *testing.T) {
+	tests := []struct {
+		name string
+		ctor interface{}
+		opts []ProvideOption
+		want string
+	}{
+		{name: "not a function", ctor: 42, want: "provider must be a function"},
+		{name: "variadic", ctor: func(...int) *testConfig { return nil }, want: "must not be variadic"},
+		{name: "no result", ctor: func() {}, want: "must return a component"},
+		{name: "second result not an error", ctor: func() (*testConfig, int) { return nil, 0 }, want: "must return a component"},
+		{name: "alias not implemented", ctor: newTestConfig, opts: []ProvideOption{As(new(testGreeter))}, want: "does not implement container.testGreeter"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := (&Container{}).Provide(tt.ctor, tt.opts...)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Provide: got %v, want error containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegisterRejectsDuplicates(t *testing.T) {
+	c := &Container{}
+	if err := c.Provide(newTestConfig); err != nil {
+		t.Fatal(err)
+	}
+	err := c.Provide(newTestOtherConfig)
+	if err == nil || !strings.Contains(err.Error(), "component *container.testConfig is already registered") {
+		t.Errorf("Provide: got %v, want duplicate error", err)
+	}
+}
+
+func TestBuildWrapsConstructorErrors(t *testing.T) {
+	c := &Container{}
+	if err := c.Provide(newTestFailing); err != nil {
+		t.Fatal(err)
+	}
+	err := c.Build()
+	if err == nil || err.Error() != "failed to initialize *container.testConfig: boom" {
+		t.Errorf("Build: got %v", err)
+	}
+}
+
+func TestResolveRequiresPointer(t *testing.T) {
+	c := &Container{}
+	if err := c.Build(); err != nil {
+		t.Fatal(err)
+	}
+	var cfg testConfig
+	if err := c.Resolve(cfg); err == nil {
+		t.Error("Resolve with a non-pointer: got nil error")
+	}
+	if _, err := Get[*testConfig](c); err == nil || err.Error() != "no provider for *container.testConfig" {
+		t.Errorf("Get: got %v", err)
+	}
+}
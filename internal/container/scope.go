@@ -0,0 +1,103 @@
+package container
+
+import (
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"reflect"
+	"sync"
+)
+
+// Scope controls how often a component is constructed
+type Scope int
+
+const (
+	// Singleton components are constructed once when the container is built
+	Singleton Scope = iota
+	// Prototype components are constructed on every lookup and injection
+	Prototype
+	// Request components are constructed once per HTTP request and torn
+	// down when the handler returns
+	Request
+)
+
+func (s Scope) String() string {
+	switch s {
+	case Singleton:
+		return "singleton"
+	case Prototype:
+		return "prototype"
+	case Request:
+		return "request"
+	default:
+		return "unknown"
+	}
+}
+
+type scopeKey struct{}
+
+// requestScope holds the request-scoped components of one request
+type requestScope struct {
+	mu        sync.Mutex
+	instances map[*definition]reflect.Value
+	order     []*definition
+	closed    bool
+}
+
+func newRequestScope() *requestScope {
+	return &requestScope{
+		instances: make(map[*definition]reflect.Value),
+	}
+}
+
+func scopeFromContext(ctx context.Context) *requestScope {
+	scope, _ := ctx.Value(scopeKey{}).(*requestScope)
+	return scope
+}
+
+func (s *requestScope) add(d *definition, value reflect.Value) {
+	s.instances[d] = value
+	s.order = append(s.order, d)
+}
+
+// close tears down the components that implement io.Closer, most recently
+// created first. Components can no longer be resolved from the scope
+// afterwards, e.g. by goroutines that outlive the request.
+func (s *requestScope) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return
+	}
+	s.closed = true
+
+	for i := len(s.order) - 1; i >= 0; i-- {
+		d := s.order[i]
+		if closer, ok := s.instances[d].Interface().(io.Closer); ok {
+			if err := closer.Close(); err != nil {
+				log.Printf("Failed to close request-scoped %s: %v", d.name, err)
+			}
+		}
+	}
+	s.instances = make(map[*definition]reflect.Value)
+	s.order = nil
+}
+
+// WithRequestScope returns a context carrying a new request scope and a
+// function that tears it down
+func WithRequestScope(ctx context.Context) (context.Context, func()) {
+	scope := newRequestScope()
+	return context.WithValue(ctx, scopeKey{}, scope), scope.close
+}
+
+// RequestScope is middleware that opens a request scope for every request,
+// so handlers can resolve request-scoped components with GetContext
+func (c *Container) RequestScope(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, closeScope := WithRequestScope(r.Context())
+		defer closeScope()
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
@@ -0,0 +1,51 @@
+package container
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+type testRequestState struct{ closed bool }
+
+func (s *testRequestState) Close() error {
+	s.closed = true
+	return nil
+}
+
+func newTestRequestState() *testRequestState { return &testRequestState{} }
+
+func TestRequestScope(t *testing.T) {
+	c := &Container{}
+	if err := c.Provide(newTestRequestState, WithScope(Request)); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Build(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Get[*testRequestState](c); err == nil {
+		t.Error("Get outside of a request: got nil error")
+	}
+
+	ctx, closeScope := WithRequestScope(context.Background())
+	first, err := GetContext[*testRequestState](ctx, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second, _ := GetContext[*testRequestState](ctx, c); second != first {
+		t.Error("request-scoped component constructed twice in one request")
+	}
+
+	closeScope()
+	if !first.closed {
+		t.Error("request-scoped component not closed with its scope")
+	}
+
+	// E.g. an async event listener running after the handler returned
+	_, err = GetContext[*testRequestState](context.WithoutCancel(ctx), c)
+	if err == nil || !strings.Contains(err.Error(), "request scope already closed") {
+		t.Errorf("GetContext after close: got %v", err)
+	}
+	closeScope()
+}
@@ -0,0 +1,132 @@
+// Code generated by wiregen. DO NOT EDIT.
+
+//go:build !wiregen
+
+package container
+
+import (
+	"fmt"
+	"time"
+
+	"go-spring.com/internal/cache"
+	"go-spring.com/internal/config"
+	"go-spring.com/internal/handler"
+	"go-spring.com/internal/repository"
+	"go-spring.com/internal/service"
+)
+
+// newContainer constructs the components of DefaultProviders in dependency
+// order and registers them in a new container. Components whose
+// conditions do not hold are skipped.
+func newContainer(cfg *config.Config, loader *config.Loader, lc *Lifecycle) (*Container, error) {
+	c, err := newRootContainer(cfg, loader, lc)
+	if err != nil {
+		return nil, err
+	}
+	var cache2 cache.Cache
+	var start time.Time
+
+	start = time.Now()
+	healthChecks := newHealthChecks(cfg)
+	if err := c.supplyBuilt(newHealthChecks, healthChecks, start); err != nil {
+		return nil, err
+	}
+
+	start = time.Now()
+	tracer := newTracer()
+	if err := c.supplyBuilt(newTracer, tracer, start); err != nil {
+		return nil, err
+	}
+
+	start = time.Now()
+	eventBus := newEventBus()
+	if err := c.supplyBuilt(newEventBus, eventBus, start); err != nil {
+		return nil, err
+	}
+
+	start = time.Now()
+	configWatcher := newConfigWatcher(loader, cfg)
+	if err := c.supplyBuilt(newConfigWatcher, configWatcher, start); err != nil {
+		return nil, err
+	}
+
+	start = time.Now()
+	dbPool, err := newDBPool(lc, healthChecks, cfg, loader, configWatcher)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize *container.dbPool: %w", err)
+	}
+	if err := c.supplyBuilt(newDBPool, dbPool, start); err != nil {
+		return nil, err
+	}
+
+	start = time.Now()
+	db := provideDB(dbPool)
+	if err := c.supplyBuilt(provideDB, db, start); err != nil {
+		return nil, err
+	}
+
+	if cfg.Cache.Type == "memory" && cache2 == nil {
+		start = time.Now()
+		memoryCache := newMemoryCache(configWatcher)
+		if err := c.supplyBuilt(newMemoryCache, memoryCache, start, As(new(cache.Cache))); err != nil {
+			return nil, err
+		}
+		cache2 = memoryCache
+	}
+
+	if cfg.Cache.Type == "redis" && cache2 == nil {
+		start = time.Now()
+		redisCache, err := newRedisCache(lc, healthChecks, cfg, configWatcher)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize *cache.ReloadableCache: %w", err)
+		}
+		if err := c.supplyBuilt(newRedisCache, redisCache, start, As(new(cache.Cache))); err != nil {
+			return nil, err
+		}
+		cache2 = redisCache
+	}
+
+	start = time.Now()
+	transactionManager := repository.NewTransactionManager(db)
+	if err := c.supplyBuilt(repository.NewTransactionManager, transactionManager, start); err != nil {
+		return nil, err
+	}
+
+	start = time.Now()
+	userRepository := repository.NewUserRepository(db)
+	if err := c.supplyBuilt(repository.NewUserRepository, userRepository, start, As(new(repository.UserStore))); err != nil {
+		return nil, err
+	}
+
+	if cache2 == nil {
+		return nil, fmt.Errorf("no provider for cache.Cache (required by *service.UserService)")
+	}
+	start = time.Now()
+	userService := service.NewUserService(userRepository, cache2, eventBus)
+	if err := c.supplyBuilt(service.NewUserService, userService, start); err != nil {
+		return nil, err
+	}
+
+	start = time.Now()
+	handler2 := handler.NewHandler(userService, healthChecks)
+	if err := c.supplyBuilt(handler.NewHandler, handler2, start); err != nil {
+		return nil, err
+	}
+
+	start = time.Now()
+	eventBusDrain := newEventBusDrain(lc, eventBus)
+	if err := c.supplyBuilt(newEventBusDrain, eventBusDrain, start); err != nil {
+		return nil, err
+	}
+
+	if cfg.AcceptsProfiles("!test") {
+		start = time.Now()
+		watcherRunner := newWatcherRunner(lc, configWatcher)
+		if err := c.supplyBuilt(newWatcherRunner, watcherRunner, start); err != nil {
+			return nil, err
+		}
+	}
+
+	c.built = true
+	return c, nil
+}
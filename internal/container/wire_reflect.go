@@ -0,0 +1,11 @@
+//go:build wiregen
+
+package container
+
+import "go-spring.com/internal/config"
+
+// newContainer wires the defaults by reflection while wiregen runs, so the
+// generator still builds when wire_gen.go is out of date
+func newContainer(cfg *config.Config, loader *config.Loader, lc *Lifecycle) (*Container, error) {
+	return buildContainer(cfg, loader, lc, nil, nil)
+}
@@ -0,0 +1,635 @@
+package container
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/token"
+	"go/types"
+	"io"
+	"path"
+	"reflect"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"go-spring.com/internal/config"
+)
+
+// containerPkg is the import path of this package, whose identifiers are
+// used unqualified in generated code
+var containerPkg = reflect.TypeOf(Container{}).PkgPath()
+
+// wireRoots are the parameters of the generated newContainer, which every
+// provider may depend on
+var wireRoots = []struct {
+	name string
+	typ  reflect.Type
+}{
+	{"cfg", reflect.TypeOf((*config.Config)(nil))},
+	{"loader", reflect.TypeOf((*config.Loader)(nil))},
+	{"lc", reflect.TypeOf((*Lifecycle)(nil))},
+}
+
+// GenerateWiring writes the source of wire_gen.go. It reads the provider
+// declarations of DefaultProviders and generates newContainer, which calls
+// each constructor in dependency order with typed arguments. Conditions
+// become if statements on the configuration. Generation fails with a
+// GraphError if a dependency has no provider or the providers form a cycle.
+func GenerateWiring(w io.Writer) error {
+	return generateWiring(w, DefaultProviders())
+}
+
+func generateWiring(w io.Writer, providers []Provider) error {
+	g := &wireGenerator{
+		imports: make(map[string]string),
+		slots:   make(map[reflect.Type]string),
+		names:   make(map[string]bool),
+	}
+	// Used by every generated file
+	g.importPackage("fmt")
+	g.importPackage("time")
+	g.importPackage(reflect.TypeOf(config.Config{}).PkgPath())
+	if err := g.load(providers); err != nil {
+		return err
+	}
+	if err := g.plan(); err != nil {
+		return err
+	}
+	source, err := g.source()
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(source)
+	return err
+}
+
+// wireComponent is a provider as seen by the generator
+type wireComponent struct {
+	def   *definition
+	fn    string // expression calling the constructor
+	local string // variable holding the component
+}
+
+// wireGenerator plans and renders newContainer
+type wireGenerator struct {
+	components []*wireComponent // in registration order
+	order      []*wireComponent // in dependency order
+	imports    map[string]string
+	slots      map[reflect.Type]string // variables for types with alternatives
+	names      map[string]bool         // identifiers in use
+}
+
+// load reads the provider declarations
+func (g *wireGenerator) load(providers []Provider) error {
+	seen := make(map[string]bool)
+	for _, p := range providers {
+		if p.Constructor == nil {
+			return fmt.Errorf("cannot generate wiring for the value %T, wiregen supports constructors only", p.Value)
+		}
+		d, err := newDefinition(p.Constructor)
+		if err != nil {
+			return err
+		}
+		if err := configure(d, p.Options); err != nil {
+			return err
+		}
+		switch {
+		case d.name != d.typ.String():
+			return fmt.Errorf("cannot generate wiring for %s: Name is not supported by wiregen", d.name)
+		case d.primary:
+			return fmt.Errorf("cannot generate wiring for %s: Primary is not supported by wiregen", d.name)
+		case d.scope != Singleton:
+			return fmt.Errorf("cannot generate wiring for %s: %s scope is not supported by wiregen", d.name, d.scope)
+		}
+		for _, dep := range d.params {
+			if dep.name != "" {
+				return fmt.Errorf("cannot generate wiring for %s: Inject is not supported by wiregen", d.name)
+			}
+		}
+		if seen[d.name] {
+			return fmt.Errorf("component %s is already registered", d.name)
+		}
+		seen[d.name] = true
+
+		fn, err := g.funcName(d.ctor)
+		if err != nil {
+			return fmt.Errorf("cannot generate wiring for %s: %w", d.name, err)
+		}
+		g.components = append(g.components, &wireComponent{def: d, fn: fn})
+	}
+	return nil
+}
+
+// root returns the newContainer parameter of type t
+func (g *wireGenerator) root(t reflect.Type) (string, bool) {
+	for _, r := range wireRoots {
+		if r.typ == t {
+			return r.name, true
+		}
+	}
+	return "", false
+}
+
+// providersOf returns the components that can be injected as t
+func (g *wireGenerator) providersOf(t reflect.Type) []*wireComponent {
+	var providers []*wireComponent
+	for _, comp := range g.components {
+		if comp.def.provides(t) {
+			providers = append(providers, comp)
+		}
+	}
+	return providers
+}
+
+// plan validates the dependency graph, orders the components and names
+// the variables
+func (g *wireGenerator) plan() error {
+	var problems []string
+	needed := make(map[reflect.Type]bool)
+	for _, comp := range g.components {
+		for _, dep := range comp.def.params {
+			if _, ok := g.root(dep.typ); ok {
+				continue
+			}
+			needed[dep.typ] = true
+			providers := g.providersOf(dep.typ)
+			if len(providers) == 0 {
+				problems = append(problems, fmt.Sprintf("no provider for %s (required by %s)", dep.typ, comp.def.name))
+				continue
+			}
+			var unconditional []string
+			for _, p := range providers {
+				if len(p.def.conditions) == 0 {
+					unconditional = append(unconditional, p.def.name)
+				}
+			}
+			if len(unconditional) > 1 {
+				problems = append(problems, fmt.Sprintf("multiple providers for %s: %s (required by %s)", dep.typ, strings.Join(unconditional, ", "), comp.def.name))
+			}
+		}
+		for _, cond := range comp.def.conditions {
+			if cond.missing != nil {
+				needed[cond.missing] = true
+			}
+		}
+	}
+
+	// Depth-first search for the dependency order and cycles. A component
+	// conditional on a missing bean comes after the unconditional
+	// providers of that bean.
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make(map[*wireComponent]int)
+	var path []*wireComponent
+	var visit func(comp *wireComponent)
+	visit = func(comp *wireComponent) {
+		switch state[comp] {
+		case visited:
+			return
+		case visiting:
+			var names []string
+			for i := len(path) - 1; i >= 0; i-- {
+				names = append([]string{path[i].def.name}, names...)
+				if path[i] == comp {
+					break
+				}
+			}
+			problems = append(problems, "dependency cycle: "+strings.Join(append(names, comp.def.name), " -> "))
+			return
+		}
+		state[comp] = visiting
+		path = append(path, comp)
+		for _, dep := range comp.def.params {
+			for _, p := range g.providersOf(dep.typ) {
+				visit(p)
+			}
+		}
+		for _, cond := range comp.def.conditions {
+			if cond.missing == nil {
+				continue
+			}
+			for _, p := range g.providersOf(cond.missing) {
+				if p != comp && !p.def.conditionalOnMissing() {
+					visit(p)
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		state[comp] = visited
+		g.order = append(g.order, comp)
+	}
+	for _, comp := range g.components {
+		visit(comp)
+	}
+	if len(problems) > 0 {
+		return &GraphError{Problems: problems}
+	}
+
+	// Types with several or conditional providers are held in a variable
+	// that is nil until one of the providers is active
+	var slotTypes []reflect.Type
+	for t := range needed {
+		providers := g.providersOf(t)
+		if len(providers) == 1 && len(providers[0].def.conditions) == 0 {
+			continue
+		}
+		switch t.Kind() {
+		case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice, reflect.Func, reflect.Chan:
+		default:
+			return fmt.Errorf("cannot generate wiring for %s: its providers are conditional, but it cannot be nil", t)
+		}
+		slotTypes = append(slotTypes, t)
+	}
+	sort.Slice(slotTypes, func(i, j int) bool {
+		return slotTypes[i].String() < slotTypes[j].String()
+	})
+
+	// Import the packages of the rendered types first, so variables do not
+	// shadow them
+	for _, t := range slotTypes {
+		if _, err := g.typeExpr(t); err != nil {
+			return err
+		}
+	}
+	for _, comp := range g.components {
+		for _, alias := range comp.def.aliases {
+			if _, err := g.typeExpr(alias); err != nil {
+				return fmt.Errorf("cannot generate wiring for %s: %w", comp.def.name, err)
+			}
+		}
+	}
+	for _, name := range []string{"c", "err", "start"} {
+		g.names[name] = true
+	}
+	for _, r := range wireRoots {
+		g.names[r.name] = true
+	}
+	for _, name := range g.imports {
+		g.names[name] = true
+	}
+
+	for _, t := range slotTypes {
+		g.slots[t] = g.identifier(typeBaseName(t))
+	}
+	for _, comp := range g.order {
+		comp.local = g.identifier(localName(comp))
+	}
+	return nil
+}
+
+// identifier returns base, or base with a number if it is taken
+func (g *wireGenerator) identifier(base string) string {
+	name := base
+	for i := 2; g.names[name] || token.IsKeyword(name) || types.Universe.Lookup(name) != nil; i++ {
+		name = base + strconv.Itoa(i)
+	}
+	g.names[name] = true
+	return name
+}
+
+// localName derives a variable name from the constructor, e.g.
+// userService for service.NewUserService
+func localName(comp *wireComponent) string {
+	name := comp.fn[strings.LastIndex(comp.fn, ".")+1:]
+	for _, prefix := range []string{"new", "New", "provide", "Provide"} {
+		if trimmed := strings.TrimPrefix(name, prefix); trimmed != name && trimmed != "" {
+			name = trimmed
+			break
+		}
+	}
+	return lowerFirst(name)
+}
+
+// typeBaseName derives a variable name from a type, e.g. cache for
+// cache.Cache
+func typeBaseName(t reflect.Type) string {
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+	if t.Name() == "" {
+		return "component"
+	}
+	return lowerFirst(t.Name())
+}
+
+func lowerFirst(s string) string {
+	// Leading initialisms are lowered as a whole, e.g. DB to db
+	runes := []rune(s)
+	for i := 0; i < len(runes) && unicode.IsUpper(runes[i]); i++ {
+		if i > 0 && i+1 < len(runes) && unicode.IsLower(runes[i+1]) {
+			break
+		}
+		runes[i] = unicode.ToLower(runes[i])
+	}
+	return string(runes)
+}
+
+// arg returns the expression passed for a parameter of type t
+func (g *wireGenerator) arg(t reflect.Type) string {
+	if name, ok := g.root(t); ok {
+		return name
+	}
+	if slot, ok := g.slots[t]; ok {
+		return slot
+	}
+	return g.providersOf(t)[0].local
+}
+
+// conditionExpr returns the Go expression for the conditions of comp, or
+// "" if it has none
+func (g *wireGenerator) conditionExpr(comp *wireComponent) (string, error) {
+	var exprs []string
+	for _, cond := range comp.def.conditions {
+		switch {
+		case cond.missing != nil:
+			if slot, ok := g.slots[cond.missing]; ok {
+				exprs = append(exprs, slot+" == nil")
+			}
+		case cond.property != "":
+			field, t, ok := propertyField(cond.property)
+			if !ok {
+				return "", fmt.Errorf("unknown configuration property %q in condition of %s", cond.property, comp.def.name)
+			}
+			if t.Kind() == reflect.String {
+				exprs = append(exprs, fmt.Sprintf("%s == %q", field, cond.value))
+			} else {
+				exprs = append(exprs, fmt.Sprintf("fmt.Sprint(%s) == %q", field, cond.value))
+			}
+		case cond.profiles != nil:
+			quoted := make([]string, len(cond.profiles))
+			for i, profile := range cond.profiles {
+				quoted[i] = strconv.Quote(profile)
+			}
+			exprs = append(exprs, fmt.Sprintf("cfg.AcceptsProfiles(%s)", strings.Join(quoted, ", ")))
+		}
+	}
+	return strings.Join(exprs, " && "), nil
+}
+
+// propertyField returns the expression selecting the configuration field
+// bound to key, e.g. cfg.Cache.Type for cache.type, and its type
+func propertyField(key string) (string, reflect.Type, bool) {
+	t := reflect.TypeOf(config.Config{})
+	expr := "cfg"
+	rest := key
+	for {
+		next := false
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			tag := field.Tag.Get("config")
+			if !field.IsExported() || tag == "-" {
+				continue
+			}
+			if tag == "" {
+				tag = strings.ToLower(field.Name)
+			}
+			section := field.Type.Kind() == reflect.Struct && field.Type.PkgPath() == t.PkgPath()
+			switch {
+			case rest == tag && !section:
+				return expr + "." + field.Name, field.Type, true
+			case section && strings.HasPrefix(rest, tag+"."):
+				expr += "." + field.Name
+				rest = rest[len(tag)+1:]
+				t = field.Type
+				next = true
+			}
+			if next {
+				break
+			}
+		}
+		if !next {
+			return "", nil, false
+		}
+	}
+}
+
+// writeComponent renders the construction and registration of comp
+func (g *wireGenerator) writeComponent(buf *bytes.Buffer, comp *wireComponent) error {
+	d := comp.def
+	cond, err := g.conditionExpr(comp)
+	if err != nil {
+		return err
+	}
+	indent := "\t"
+	if cond != "" {
+		fmt.Fprintf(buf, "\tif %s {\n", cond)
+		indent = "\t\t"
+	}
+
+	args := make([]string, len(d.params))
+	for i, dep := range d.params {
+		args[i] = g.arg(dep.typ)
+		if _, ok := g.slots[dep.typ]; ok {
+			fmt.Fprintf(buf, "%sif %s == nil {\n", indent, args[i])
+			fmt.Fprintf(buf, "%s\treturn nil, fmt.Errorf(%q)\n", indent, fmt.Sprintf("no provider for %s (required by %s)", dep.typ, d.name))
+			fmt.Fprintf(buf, "%s}\n", indent)
+		}
+	}
+
+	fmt.Fprintf(buf, "%sstart = time.Now()\n", indent)
+	call := fmt.Sprintf("%s(%s)", comp.fn, strings.Join(args, ", "))
+	if d.ctor.Type().NumOut() == 2 {
+		fmt.Fprintf(buf, "%s%s, err := %s\n", indent, comp.local, call)
+		fmt.Fprintf(buf, "%sif err != nil {\n", indent)
+		fmt.Fprintf(buf, "%s\treturn nil, fmt.Errorf(\"failed to initialize %s: %%w\", err)\n", indent, d.name)
+		fmt.Fprintf(buf, "%s}\n", indent)
+	} else {
+		fmt.Fprintf(buf, "%s%s := %s\n", indent, comp.local, call)
+	}
+
+	opts := []string{comp.fn, comp.local, "start"}
+	for _, alias := range d.aliases {
+		expr, err := g.typeExpr(alias)
+		if err != nil {
+			return err
+		}
+		opts = append(opts, fmt.Sprintf("As(new(%s))", expr))
+	}
+	fmt.Fprintf(buf, "%sif err := c.supplyBuilt(%s); err != nil {\n", indent, strings.Join(opts, ", "))
+	fmt.Fprintf(buf, "%s\treturn nil, err\n", indent)
+	fmt.Fprintf(buf, "%s}\n", indent)
+
+	for _, t := range append([]reflect.Type{d.typ}, d.aliases...) {
+		slot, ok := g.slots[t]
+		if !ok {
+			continue
+		}
+		if !conditionalOnMissing(d, t) && len(g.providersOf(t)) > 1 {
+			fmt.Fprintf(buf, "%sif %s != nil {\n", indent, slot)
+			fmt.Fprintf(buf, "%s\treturn nil, fmt.Errorf(%q)\n", indent, fmt.Sprintf("multiple providers for %s", t))
+			fmt.Fprintf(buf, "%s}\n", indent)
+		}
+		fmt.Fprintf(buf, "%s%s = %s\n", indent, slot, comp.local)
+	}
+
+	if cond != "" {
+		buf.WriteString("\t}\n")
+	}
+	buf.WriteString("\n")
+	return nil
+}
+
+// conditionalOnMissing reports whether d is only active while no other
+// component provides t
+func conditionalOnMissing(d *definition, t reflect.Type) bool {
+	for _, cond := range d.conditions {
+		if cond.missing == t {
+			return true
+		}
+	}
+	return false
+}
+
+// functionName returns the package qualified name of fn, e.g.
+// go-spring.com/internal/repository.NewUserRepository
+func functionName(fn reflect.Value) string {
+	if f := runtime.FuncForPC(fn.Pointer()); f != nil {
+		return f.Name()
+	}
+	return ""
+}
+
+// funcName returns the qualified name of a top-level function
+func (g *wireGenerator) funcName(fn reflect.Value) (string, error) {
+	full := functionName(fn)
+	dot := strings.LastIndex(full, ".")
+	slash := strings.LastIndex(full, "/")
+	if dot < slash {
+		return "", fmt.Errorf("unexpected function name %s", full)
+	}
+	pkgPath, name := full[:dot], full[dot+1:]
+	if strings.Contains(pkgPath[slash+1:], ".") || !token.IsIdentifier(name) || strings.HasPrefix(name, "func") {
+		return "", fmt.Errorf("provider %s is not a top-level function", full)
+	}
+	if pkgPath == containerPkg {
+		return name, nil
+	}
+	return g.importPackage(pkgPath) + "." + name, nil
+}
+
+// typeExpr returns the Go expression for t
+func (g *wireGenerator) typeExpr(t reflect.Type) (string, error) {
+	switch {
+	case t.Name() != "":
+		if t.PkgPath() == "" || t.PkgPath() == containerPkg {
+			return t.Name(), nil
+		}
+		return g.importPackage(t.PkgPath()) + "." + t.Name(), nil
+	case t.Kind() == reflect.Ptr:
+		elem, err := g.typeExpr(t.Elem())
+		return "*" + elem, err
+	case t.Kind() == reflect.Slice:
+		elem, err := g.typeExpr(t.Elem())
+		return "[]" + elem, err
+	case t.Kind() == reflect.Interface && t.NumMethod() == 0:
+		return "interface{}", nil
+	default:
+		return "", fmt.Errorf("cannot generate type expression for %s", t)
+	}
+}
+
+// importPackage returns the name to refer to pkgPath by
+func (g *wireGenerator) importPackage(pkgPath string) string {
+	if name, ok := g.imports[pkgPath]; ok {
+		return name
+	}
+	base := path.Base(pkgPath)
+	name := base
+	for i := 2; g.packageNameTaken(name); i++ {
+		name = fmt.Sprintf("%s%d", base, i)
+	}
+	g.imports[pkgPath] = name
+	return name
+}
+
+func (g *wireGenerator) packageNameTaken(name string) bool {
+	for _, existing := range g.imports {
+		if existing == name {
+			return true
+		}
+	}
+	return false
+}
+
+// source renders and formats the generated file
+func (g *wireGenerator) source() ([]byte, error) {
+	var body bytes.Buffer
+	for _, t := range sortedSlotTypes(g.slots) {
+		expr, err := g.typeExpr(t)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(&body, "\tvar %s %s\n", g.slots[t], expr)
+	}
+	body.WriteString("\tvar start time.Time\n\n")
+	for _, comp := range g.order {
+		if err := g.writeComponent(&body, comp); err != nil {
+			return nil, err
+		}
+	}
+	var buf bytes.Buffer
+	buf.WriteString("// Code generated by wiregen. DO NOT EDIT.\n\n")
+	buf.WriteString("//go:build !wiregen\n\n")
+	buf.WriteString("package container\n\n")
+
+	paths := make([]string, 0, len(g.imports))
+	for pkgPath := range g.imports {
+		paths = append(paths, pkgPath)
+	}
+	// The standard library first, then the other packages
+	isStdlib := func(pkgPath string) bool {
+		return !strings.Contains(strings.Split(pkgPath, "/")[0], ".")
+	}
+	sort.Slice(paths, func(i, j int) bool {
+		if isStdlib(paths[i]) != isStdlib(paths[j]) {
+			return isStdlib(paths[i])
+		}
+		return paths[i] < paths[j]
+	})
+	buf.WriteString("import (\n")
+	for i, pkgPath := range paths {
+		if i > 0 && isStdlib(paths[i-1]) && !isStdlib(pkgPath) {
+			buf.WriteString("\n")
+		}
+		if name := g.imports[pkgPath]; name != path.Base(pkgPath) {
+			fmt.Fprintf(&buf, "\t%s %q\n", name, pkgPath)
+		} else {
+			fmt.Fprintf(&buf, "\t%q\n", pkgPath)
+		}
+	}
+	buf.WriteString(")\n\n")
+
+	buf.WriteString("// newContainer constructs the components of DefaultProviders in dependency\n")
+	buf.WriteString("// order and registers them in a new container. Components whose\n")
+	buf.WriteString("// conditions do not hold are skipped.\n")
+	buf.WriteString("func newContainer(cfg *config.Config, loader *config.Loader, lc *Lifecycle) (*Container, error) {\n")
+	buf.WriteString("\tc, err := newRootContainer(cfg, loader, lc)\n")
+	buf.WriteString("\tif err != nil {\n\t\treturn nil, err\n\t}\n")
+	buf.Write(body.Bytes())
+	buf.WriteString("\tc.built = true\n")
+	buf.WriteString("\treturn c, nil\n")
+	buf.WriteString("}\n")
+
+	source, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("failed to format generated wiring: %w", err)
+	}
+	return source, nil
+}
+
+func sortedSlotTypes(slots map[reflect.Type]string) []reflect.Type {
+	types := make([]reflect.Type, 0, len(slots))
+	for t := range slots {
+		types = append(types, t)
+	}
+	sort.Slice(types, func(i, j int) bool {
+		return slots[types[i]] < slots[types[j]]
+	})
+	return types
+}
@@ -0,0 +1,147 @@
+package container
+
+import (
+	"bytes"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"go-spring.com/internal/config"
+)
+
+func TestGeneratedWiringIsUpToDate(t *testing.T) {
+	var buf bytes.Buffer
+	if err := GenerateWiring(&buf); err != nil {
+		t.Fatalf("GenerateWiring: %v", err)
+	}
+	current, err := os.ReadFile("wire_gen.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf.Bytes(), current) {
+		t.Error("wire_gen.go is out of date, run go generate ./internal/container")
+	}
+}
+
+func TestGeneratedWiringMatchesReflection(t *testing.T) {
+	// The configuration of containertest, as the Redis cache would connect
+	cfg := &config.Config{}
+	if err := config.Bind(config.DefaultValues(), cfg); err != nil {
+		t.Fatal(err)
+	}
+	cfg.Database.URL = "postgres://localhost:5432/test?sslmode=disable"
+	cfg.Profiles = []string{"test"}
+	cfg.Cache.Type = "memory"
+
+	typed, err := newContainer(cfg, config.NewLoader(), NewLifecycle())
+	if err != nil {
+		t.Fatalf("newContainer: %v", err)
+	}
+	defer typed.Close()
+	reflective, err := buildContainer(cfg, config.NewLoader(), NewLifecycle(), nil, nil)
+	if err != nil {
+		t.Fatalf("buildContainer: %v", err)
+	}
+	defer reflective.Close()
+
+	if got, want := wiredBeans(typed), wiredBeans(reflective); !reflect.DeepEqual(got, want) {
+		t.Errorf("generated wiring:\n%v\nwant:\n%v", got, want)
+	}
+}
+
+// wiredBeans describes the components of c without their timings
+func wiredBeans(c *Container) []string {
+	var beans []string
+	for _, bean := range c.Beans() {
+		beans = append(beans, strings.Join([]string{
+			bean.Name,
+			bean.Type,
+			strings.Join(bean.Aliases, ","),
+			strings.Join(bean.Dependencies, ","),
+		}, " "))
+	}
+	sort.Strings(beans)
+	return beans
+}
+
+func TestGenerateWiringRejectsInvalidGraphs(t *testing.T) {
+	tests := []struct {
+		name      string
+		providers []Provider
+		want      string
+	}{
+		{
+			name:      "missing provider",
+			providers: []Provider{{Constructor: newTestService}},
+			want:      "no provider for *container.testRepo (required by *container.testService)",
+		},
+		{
+			name: "ambiguous interface",
+			providers: []Provider{
+				{Constructor: newTestEnglish, Options: []ProvideOption{As(new(testGreeter))}},
+				{Constructor: newTestFrench, Options: []ProvideOption{As(new(testGreeter))}},
+				{Constructor: newTestGreeting},
+			},
+			want: "multiple providers for container.testGreeter",
+		},
+		{
+			name: "cycle",
+			providers: []Provider{
+				{Constructor: newTestCycleA},
+				{Constructor: newTestCycleB},
+				{Constructor: newTestCycleC},
+			},
+			want: "dependency cycle: *container.testCycleA -> *container.testCycleB -> *container.testCycleC -> *container.testCycleA",
+		},
+		{
+			name:      "value",
+			providers: []Provider{{Value: &testConfig{}}},
+			want:      "wiregen supports constructors only",
+		},
+		{
+			name:      "named component",
+			providers: []Provider{{Constructor: newTestConfig, Options: []ProvideOption{Name("primary")}}},
+			want:      "Name is not supported by wiregen",
+		},
+		{
+			name: "unknown property",
+			providers: []Provider{
+				{Constructor: newTestConfig, Options: []ProvideOption{ConditionalOnProperty("cache.kind", "memory")}},
+			},
+			want: `unknown configuration property "cache.kind"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := generateWiring(&bytes.Buffer{}, tt.providers)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("generateWiring: got %v, want error containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestPropertyField(t *testing.T) {
+	tests := []struct {
+		key  string
+		want string
+		ok   bool
+	}{
+		{key: "cache.type", want: "cfg.Cache.Type", ok: true},
+		{key: "cache.redis.port", want: "cfg.Cache.Redis.Port", ok: true},
+		{key: "cache", ok: false},
+		{key: "cache.missing", ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			got, _, ok := propertyField(tt.key)
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("propertyField(%q): got %q, %v, want %q, %v", tt.key, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,176 @@
+package event
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"reflect"
+	"sort"
+	"sync"
+
+	"go-spring.com/internal/repository"
+)
+
+// Bus publishes application events to the listeners subscribed to their
+// type
+type Bus struct {
+	mu        sync.RWMutex
+	listeners []*listener
+	closed    bool
+	running   sync.WaitGroup
+}
+
+type listener struct {
+	typ    reflect.Type
+	order  int
+	async  bool
+	handle func(ctx context.Context, event interface{}) error
+}
+
+// ListenerOption configures a listener
+type ListenerOption func(*listener)
+
+// Async runs the listener in its own goroutine. Publish does not wait for
+// it and its error is only logged. Once the bus is closing, the listener
+// runs synchronously instead.
+func Async() ListenerOption {
+	return func(l *listener) {
+		l.async = true
+	}
+}
+
+// Order sets the position of the listener. Listeners with a lower order
+// are called first and listeners with the same order in the order they
+// subscribed. The default is 0.
+func Order(order int) ListenerOption {
+	return func(l *listener) {
+		l.order = order
+	}
+}
+
+// NewBus creates a bus without listeners
+func NewBus() *Bus {
+	return &Bus{}
+}
+
+// Subscribe registers fn for events of type E. A listener for an interface
+// type receives every event implementing it.
+func Subscribe[E any](b *Bus, fn func(ctx context.Context, event E) error, opts ...ListenerOption) {
+	l := &listener{
+		typ: reflect.TypeOf((*E)(nil)).Elem(),
+		handle: func(ctx context.Context, event interface{}) error {
+			return fn(ctx, event.(E))
+		},
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.listeners = append(b.listeners, l)
+	sort.SliceStable(b.listeners, func(i, j int) bool {
+		return b.listeners[i].order < b.listeners[j].order
+	})
+}
+
+// Publish delivers event to its listeners in order. Synchronous listeners
+// have returned when Publish returns; an error or panic in one of them does
+// not stop the others and their errors are returned joined.
+func (b *Bus) Publish(ctx context.Context, event interface{}) error {
+	typ := reflect.TypeOf(event)
+	b.mu.RLock()
+	var matched []*listener
+	for _, l := range b.listeners {
+		if typ != nil && typ.AssignableTo(l.typ) {
+			matched = append(matched, l)
+		}
+	}
+	// Count the async listeners under the lock, so that Close cannot start
+	// waiting in between
+	async := !b.closed
+	if async {
+		for _, l := range matched {
+			if l.async {
+				b.running.Add(1)
+			}
+		}
+	}
+	b.mu.RUnlock()
+
+	var errs []error
+	for _, l := range matched {
+		if l.async {
+			run := func(l *listener) {
+				if err := l.call(context.WithoutCancel(ctx), event); err != nil {
+					log.Printf("Async event listener failed: %v", err)
+				}
+			}
+			if !async {
+				run(l)
+				continue
+			}
+			go func(l *listener) {
+				defer b.running.Done()
+				run(l)
+			}(l)
+			continue
+		}
+		if err := l.call(ctx, event); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// PublishAfterCommit publishes event once the transaction carried by ctx
+// has committed and drops it if the transaction rolls back. Without a
+// transaction the event is published immediately. Errors of listeners
+// called after the commit are logged, as the caller has already returned.
+func (b *Bus) PublishAfterCommit(ctx context.Context, event interface{}) error {
+	tx, ok := repository.TransactionFromContext(ctx)
+	if !ok {
+		return b.Publish(ctx, event)
+	}
+	tx.AfterCommit(func() {
+		if err := b.Publish(context.WithoutCancel(ctx), event); err != nil {
+			log.Printf("Event listener failed after commit: %v", err)
+		}
+	})
+	return nil
+}
+
+// Close waits until the running asynchronous listeners have returned or
+// ctx is done. Events published afterwards are delivered to asynchronous
+// listeners synchronously.
+func (b *Bus) Close(ctx context.Context) error {
+	b.mu.Lock()
+	b.closed = true
+	b.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		b.running.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("event listeners still running: %w", ctx.Err())
+	}
+}
+
+// call runs the listener, turning a panic into an error
+func (l *listener) call(ctx context.Context, event interface{}) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("listener for %s panicked: %v", l.typ, r)
+		}
+	}()
+	if err := l.handle(ctx, event); err != nil {
+		return fmt.Errorf("listener for %s failed: %w", l.typ, err)
+	}
+	return nil
+}
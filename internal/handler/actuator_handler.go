@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"go-spring.com/internal/config"
+	"go-spring.com/internal/server/router"
+)
+
+// ActuatorHandler serves operational endpoints describing the running
+// application
+type ActuatorHandler struct {
+	config   func() *config.Config
+	beans    func() interface{}
+	beansDOT func(io.Writer) error
+}
+
+// NewActuatorHandler creates a new actuator handler. The config function
+// returns the current configuration, which may change on reload.
+func NewActuatorHandler(config func() *config.Config) *ActuatorHandler {
+	return &ActuatorHandler{
+		config: config,
+	}
+}
+
+// WithBeans enables /actuator/beans. The beans function returns the
+// application's components and dot writes their dependency graph in
+// Graphviz DOT format.
+func (h *ActuatorHandler) WithBeans(beans func() interface{}, dot func(io.Writer) error) *ActuatorHandler {
+	h.beans = beans
+	h.beansDOT = dot
+	return h
+}
+
+// RegisterRoutes registers the actuator routes
+func (h *ActuatorHandler) RegisterRoutes(r *router.Router) {
+	actuator := r.Group("/actuator")
+	actuator.Get("/env", h.handleEnv).Name("actuator.env")
+	if h.beans != nil {
+		actuator.Get("/beans", h.handleBeans).Name("actuator.beans")
+	}
+}
+
+// handleEnv handles GET /actuator/env
+func (h *ActuatorHandler) handleEnv(w http.ResponseWriter, r *http.Request) {
+	// Return effective configuration with secrets masked
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.config().Environment())
+}
+
+// handleBeans handles GET /actuator/beans. With ?format=dot the dependency
+// graph is returned in Graphviz DOT format instead of JSON.
+func (h *ActuatorHandler) handleBeans(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Query().Get("format") {
+	case "", "json":
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"beans": h.beans(),
+		})
+	case "dot":
+		w.Header().Set("Content-Type", "text/vnd.graphviz")
+		h.beansDOT(w)
+	default:
+		http.Error(w, "Unsupported format", http.StatusBadRequest)
+	}
+}
@@ -6,60 +6,61 @@ import (
 
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 
-	"go-spring.com/internal/container"
+	"go-spring.com/internal/observability"
+	"go-spring.com/internal/server/router"
 	"go-spring.com/internal/service"
 )
 
 // Handler manages all HTTP handlers
 type Handler struct {
+	userService *service.UserService
 	userHandler *UserHandler
+	health      *observability.HealthChecks
 }
 
-// NewHandler creates a new handler
-func NewHandler(userService *service.UserService) *Handler {
+// NewHandler creates a new handler. Dependencies are passed in explicitly,
+// so several handlers backed by different containers can coexist.
+func NewHandler(userService *service.UserService, health *observability.HealthChecks) *Handler {
 	return &Handler{
+		userService: userService,
 		userHandler: NewUserHandler(userService),
+		health:      health,
 	}
 }
 
-// RegisterRoutes registers all routes
-func (h *Handler) RegisterRoutes(mux *http.ServeMux) {
-	// Health check endpoint
-	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusOK)
-		w.Write([]byte("OK"))
-	})
-
-	// Prometheus metrics endpoint
-	mux.Handle("/metrics", promhttp.Handler())
+// RegisterRoutes registers the public API routes
+func (h *Handler) RegisterRoutes(r *router.Router) {
+	api := r.Group("/api")
 
 	// Register user routes
-	h.userHandler.RegisterRoutes(mux)
-
-	// Example endpoint with caching
-	mux.HandleFunc("/api/example", func(w http.ResponseWriter, r *http.Request) {
-		switch r.Method {
-		case http.MethodGet:
-			handleGetExample(w, r, container.GetContainer())
-		default:
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		}
-	})
-
-	// Example endpoint with parameter and caching
-	mux.HandleFunc("/api/example/", func(w http.ResponseWriter, r *http.Request) {
-		switch r.Method {
-		case http.MethodGet:
-			handleGetExampleWithParam(w, r, container.GetContainer())
-		default:
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	h.userHandler.RegisterRoutes(api)
+
+	// Example endpoints with caching
+	api.Get("/example", h.handleGetExample).Name("example.list")
+	api.Get("/example/{id}", h.handleGetExampleWithParam).Name("example.get")
+}
+
+// RegisterManagementRoutes registers the health and metrics routes, which
+// are served on the management port
+func (h *Handler) RegisterManagementRoutes(r *router.Router) {
+	// Health check endpoint, unavailable only when a required dependency
+	// is down
+	r.Get("/health", func(w http.ResponseWriter, r *http.Request) {
+		report := h.health.Check(r.Context())
+		w.Header().Set("Content-Type", "application/json")
+		if report.Status == observability.HealthStatusUnhealthy {
+			w.WriteHeader(http.StatusServiceUnavailable)
 		}
-	})
+		json.NewEncoder(w).Encode(report)
+	}).Name("health")
+
+	// Prometheus metrics endpoint
+	r.Handle(http.MethodGet, "/metrics", promhttp.Handler()).Name("metrics")
 }
 
-func handleGetExample(w http.ResponseWriter, r *http.Request, container *container.Container) {
+func (h *Handler) handleGetExample(w http.ResponseWriter, r *http.Request) {
 	// Get data from service (will use cache)
-	result, err := container.GetUserService().GetUserByID(r.Context(), 1)
+	result, err := h.userService.GetUserByID(r.Context(), 1)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -74,16 +75,9 @@ func handleGetExample(w http.ResponseWriter, r *http.Request, container *contain
 	json.NewEncoder(w).Encode(response)
 }
 
-func handleGetExampleWithParam(w http.ResponseWriter, r *http.Request, container *container.Container) {
-	// Extract ID from URL path
-	id := r.URL.Path[len("/api/example/"):]
-	if id == "" {
-		http.Error(w, "ID is required", http.StatusBadRequest)
-		return
-	}
-
+func (h *Handler) handleGetExampleWithParam(w http.ResponseWriter, r *http.Request) {
 	// Get data from service (will use cache)
-	result, err := container.GetUserService().GetUserByID(r.Context(), 1)
+	result, err := h.userService.GetUserByID(r.Context(), 1)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -4,10 +4,10 @@ import (
 	"context"
 	"encoding/json"
 	"net/http"
-	"strconv"
 
 	"go-spring.com/internal/observability"
 	"go-spring.com/internal/repository"
+	"go-spring.com/internal/server/router"
 	"go-spring.com/internal/service"
 )
 
@@ -25,43 +25,13 @@ func NewUserHandler(userService *service.UserService) *UserHandler {
 	}
 }
 
-// RegisterRoutes registers the user routes
-func (h *UserHandler) RegisterRoutes(mux *http.ServeMux) {
-	mux.HandleFunc("/api/users", h.handleUsers)
-	mux.HandleFunc("/api/users/", h.handleUser)
-}
-
-// handleUsers handles /api/users endpoints
-func (h *UserHandler) handleUsers(w http.ResponseWriter, r *http.Request) {
-
-	switch r.Method {
-	case http.MethodPost:
-		h.createUser(w, r)
-	case http.MethodGet:
-		h.getUserByUsername(w, r)
-	default:
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-	}
-}
-
-// handleUser handles /api/users/{id} endpoints
-func (h *UserHandler) handleUser(w http.ResponseWriter, r *http.Request) {
-	// Extract user ID from URL
-	idStr := r.URL.Path[len("/api/users/"):]
-	id, err := strconv.ParseInt(idStr, 10, 64)
-	if err != nil {
-		http.Error(w, "Invalid user ID", http.StatusBadRequest)
-		return
-	}
-
-	switch r.Method {
-	case http.MethodGet:
-		h.getUserByID(w, r, id)
-	case http.MethodPut:
-		h.updateUser(w, r, id)
-	default:
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-	}
+// RegisterRoutes registers the user routes under /users
+func (h *UserHandler) RegisterRoutes(r *router.Router) {
+	users := r.Group("/users")
+	users.Post("", h.createUser).Name("users.create")
+	users.Get("", h.getUserByUsername).Name("users.find")
+	users.Get("/{id}", h.getUserByID).Name("users.get")
+	users.Put("/{id}", h.updateUser).Name("users.update")
 }
 
 // createUser handles POST /api/users
@@ -91,9 +61,15 @@ func (h *UserHandler) createUser(w http.ResponseWriter, r *http.Request) {
 }
 
 // getUserByID handles GET /api/users/{id}
-func (h *UserHandler) getUserByID(w http.ResponseWriter, r *http.Request, id int64) {
+func (h *UserHandler) getUserByID(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
+	id, err := router.PathParam[int64](r, "id")
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
 	// Get user
 	user, err := observability.TraceFunctionWithResult(h.tracer, ctx, "GetUserByID", func(ctx context.Context) (*repository.User, error) {
 		return h.userService.GetUserByID(ctx, id)
@@ -117,8 +93,8 @@ func (h *UserHandler) getUserByUsername(w http.ResponseWriter, r *http.Request)
 	ctx := r.Context()
 
 	// Get username from query parameter
-	username := r.URL.Query().Get("username")
-	if username == "" {
+	username, err := router.QueryParam[string](r, "username")
+	if err != nil {
 		http.Error(w, "Username is required", http.StatusBadRequest)
 		return
 	}
@@ -142,9 +118,15 @@ func (h *UserHandler) getUserByUsername(w http.ResponseWriter, r *http.Request)
 }
 
 // updateUser handles PUT /api/users/{id}
-func (h *UserHandler) updateUser(w http.ResponseWriter, r *http.Request, id int64) {
+func (h *UserHandler) updateUser(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
+	id, err := router.PathParam[int64](r, "id")
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
 	// Parse request body
 	var user repository.User
 	if err := json.NewDecoder(r.Body).Decode(&user); err != nil {
@@ -156,7 +138,7 @@ func (h *UserHandler) updateUser(w http.ResponseWriter, r *http.Request, id int6
 	user.ID = id
 
 	// Update user
-	err := observability.TraceFunction(h.tracer, ctx, "UpdateUser", func(ctx context.Context) error {
+	err = observability.TraceFunction(h.tracer, ctx, "UpdateUser", func(ctx context.Context) error {
 		return h.userService.UpdateUser(ctx, &user)
 	})
 	if err != nil {
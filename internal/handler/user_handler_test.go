@@ -0,0 +1,72 @@
+package handler_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go-spring.com/internal/container"
+	"go-spring.com/internal/container/containertest"
+	"go-spring.com/internal/repository"
+)
+
+func newTestHandler(t *testing.T) http.Handler {
+	return containertest.New().
+		Override(repository.NewMemoryUserStore, container.As(new(repository.UserStore))).
+		Handler(t)
+}
+
+func TestUserHandlerCreateAndGet(t *testing.T) {
+	h := newTestHandler(t)
+
+	body := strings.NewReader(`{"username":"alice","email":"alice@example.com"}`)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/users", body))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("POST /api/users: got status %d, want %d: %s", rec.Code, http.StatusCreated, rec.Body)
+	}
+	var created repository.User
+	if err := json.NewDecoder(rec.Body).Decode(&created); err != nil {
+		t.Fatalf("failed to decode created user: %v", err)
+	}
+	if created.ID != 1 {
+		t.Fatalf("created user ID: got %d, want 1", created.ID)
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/users/1", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /api/users/1: got status %d, want %d: %s", rec.Code, http.StatusOK, rec.Body)
+	}
+	var got repository.User
+	if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+		t.Fatalf("failed to decode user: %v", err)
+	}
+	if got.Username != "alice" || got.Email != "alice@example.com" {
+		t.Fatalf("GET /api/users/1: got %+v", got)
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/users/2", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("GET /api/users/2: got status %d, want %d", rec.Code, http.StatusNotFound)
+	}
+}
+
+func TestUserHandlerMethodNotAllowed(t *testing.T) {
+	h := newTestHandler(t)
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/api/users/1", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("DELETE /api/users/1: got status %d, want %d", rec.Code, http.StatusMethodNotAllowed)
+	}
+	allow := rec.Header().Get("Allow")
+	for _, method := range []string{http.MethodGet, http.MethodPut} {
+		if !strings.Contains(allow, method) {
+			t.Errorf("Allow header %q does not contain %s", allow, method)
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package observability
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+// HealthStatusDegraded means optional dependencies are unavailable while
+// the application keeps serving
+const HealthStatusDegraded HealthStatus = "degraded"
+
+// CheckPolicy controls how health checks are run at startup. Each check is
+// attempted up to Attempts times, waiting InitialDelay after the first
+// failure and doubling the wait up to MaxDelay. Every attempt is bounded by
+// Timeout. Checks named in Optional do not prevent startup.
+type CheckPolicy struct {
+	Attempts     int
+	InitialDelay time.Duration
+	MaxDelay     time.Duration
+	Timeout      time.Duration
+	Optional     []string
+}
+
+// HealthCheck probes a dependency of the application
+type HealthCheck struct {
+	Name     string
+	Required bool
+	Check    func(ctx context.Context) error
+}
+
+// CheckResult is the outcome of a health check
+type CheckResult struct {
+	Name     string       `json:"name"`
+	Status   HealthStatus `json:"status"`
+	Required bool         `json:"required"`
+	Attempts int          `json:"attempts,omitempty"`
+	Error    string       `json:"error,omitempty"`
+}
+
+// HealthReport summarizes the health checks. The status is unhealthy if a
+// required check failed and degraded if only optional checks failed.
+type HealthReport struct {
+	Status HealthStatus  `json:"status"`
+	Checks []CheckResult `json:"checks"`
+}
+
+// HealthChecks collects the checks of the application's dependencies
+type HealthChecks struct {
+	policy CheckPolicy
+	mu     sync.Mutex
+	checks []HealthCheck
+}
+
+// NewHealthChecks creates an empty set of checks run with policy
+func NewHealthChecks(policy CheckPolicy) *HealthChecks {
+	return &HealthChecks{policy: policy}
+}
+
+// Register adds a check for the dependency name. It is required unless
+// the policy lists name as optional.
+func (h *HealthChecks) Register(name string, check func(ctx context.Context) error) {
+	required := true
+	for _, optional := range h.policy.Optional {
+		if optional == name {
+			required = false
+		}
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.checks = append(h.checks, HealthCheck{Name: name, Required: required, Check: check})
+}
+
+// WaitReady runs every check with retries and backoff, concurrently. It
+// returns an error naming the failed dependencies if a required check
+// still fails after all attempts. Failed optional checks are logged and
+// reported as degraded. With zero attempts the checks are skipped.
+func (h *HealthChecks) WaitReady(ctx context.Context) (*HealthReport, error) {
+	if h.policy.Attempts <= 0 {
+		return &HealthReport{Status: HealthStatusHealthy}, nil
+	}
+	report := h.run(ctx, h.policy.Attempts)
+
+	var failed []string
+	for _, result := range report.Checks {
+		if result.Status == HealthStatusHealthy {
+			continue
+		}
+		if result.Required {
+			failed = append(failed, fmt.Sprintf("%s: %s", result.Name, result.Error))
+		} else {
+			log.Printf("WARNING: optional dependency %s is unavailable, starting in degraded mode: %s", result.Name, result.Error)
+		}
+	}
+	if len(failed) > 0 {
+		return report, fmt.Errorf("required dependencies unavailable: %s", strings.Join(failed, "; "))
+	}
+	return report, nil
+}
+
+// Check runs every check once
+func (h *HealthChecks) Check(ctx context.Context) *HealthReport {
+	return h.run(ctx, 1)
+}
+
+func (h *HealthChecks) run(ctx context.Context, attempts int) *HealthReport {
+	h.mu.Lock()
+	checks := append([]HealthCheck(nil), h.checks...)
+	h.mu.Unlock()
+
+	report := &HealthReport{
+		Status: HealthStatusHealthy,
+		Checks: make([]CheckResult, len(checks)),
+	}
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func(i int, check HealthCheck) {
+			defer wg.Done()
+			report.Checks[i] = h.runCheck(ctx, check, attempts)
+		}(i, check)
+	}
+	wg.Wait()
+
+	for _, result := range report.Checks {
+		switch {
+		case result.Status == HealthStatusHealthy:
+		case result.Required:
+			report.Status = HealthStatusUnhealthy
+		case report.Status == HealthStatusHealthy:
+			report.Status = HealthStatusDegraded
+		}
+	}
+	return report
+}
+
+// runCheck attempts a check until it succeeds or attempts run out
+func (h *HealthChecks) runCheck(ctx context.Context, check HealthCheck, attempts int) CheckResult {
+	result := CheckResult{Name: check.Name, Required: check.Required}
+	delay := h.policy.InitialDelay
+
+	var err error
+	for result.Attempts < attempts {
+		result.Attempts++
+		if err = h.attempt(ctx, check); err == nil {
+			break
+		}
+		if result.Attempts == attempts {
+			break
+		}
+
+		log.Printf("Health check %s failed (attempt %d/%d), retrying in %s: %v",
+			check.Name, result.Attempts, attempts, delay, err)
+		select {
+		case <-ctx.Done():
+			err = ctx.Err()
+			attempts = result.Attempts
+		case <-time.After(delay):
+		}
+		if delay *= 2; h.policy.MaxDelay > 0 && delay > h.policy.MaxDelay {
+			delay = h.policy.MaxDelay
+		}
+	}
+
+	if err != nil {
+		result.Status = HealthStatusUnhealthy
+		result.Error = err.Error()
+		DependencyUp.WithLabelValues(check.Name).Set(0)
+	} else {
+		result.Status = HealthStatusHealthy
+		DependencyUp.WithLabelValues(check.Name).Set(1)
+	}
+	return result
+}
+
+func (h *HealthChecks) attempt(ctx context.Context, check HealthCheck) error {
+	if h.policy.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, h.policy.Timeout)
+		defer cancel()
+	}
+	return check.Check(ctx)
+}
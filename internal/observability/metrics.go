@@ -38,24 +38,57 @@ func NewMetricsConfig() *MetricsConfig {
 	}
 }
 
+// httpLabels are the labels of the HTTP request metrics. The route is the
+// matched route template, e.g. /api/users/{id}, so that the number of
+// series stays bounded.
+var httpLabels = []string{"method", "route", "status", "status_class"}
+
 var (
-	// HTTPRequestDuration tracks HTTP request duration
+	// HTTPRequestDuration tracks HTTP request duration by route template
 	HTTPRequestDuration = promauto.NewHistogramVec(
 		prometheus.HistogramOpts{
 			Name:    "http_request_duration_seconds",
 			Help:    "Duration of HTTP requests in seconds",
 			Buckets: prometheus.DefBuckets,
 		},
-		[]string{"method", "path", "status"},
+		httpLabels,
 	)
 
-	// HTTPRequestsTotal tracks total HTTP requests
+	// HTTPRequestsTotal tracks total HTTP requests by route template
 	HTTPRequestsTotal = promauto.NewCounterVec(
 		prometheus.CounterOpts{
 			Name: "http_requests_total",
 			Help: "Total number of HTTP requests",
 		},
-		[]string{"method", "path", "status"},
+		httpLabels,
+	)
+
+	// HTTPRequestSize tracks the size of request bodies
+	HTTPRequestSize = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "http_request_size_bytes",
+			Help:    "Size of HTTP request bodies in bytes",
+			Buckets: prometheus.ExponentialBuckets(100, 10, 7),
+		},
+		httpLabels,
+	)
+
+	// HTTPResponseSize tracks the size of response bodies
+	HTTPResponseSize = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "http_response_size_bytes",
+			Help:    "Size of HTTP response bodies in bytes",
+			Buckets: prometheus.ExponentialBuckets(100, 10, 7),
+		},
+		httpLabels,
+	)
+
+	// HTTPRequestsInFlight tracks the requests being served
+	HTTPRequestsInFlight = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "http_requests_in_flight",
+			Help: "Number of HTTP requests being served",
+		},
 	)
 
 	// CacheHits tracks cache hit rate
@@ -76,6 +109,24 @@ var (
 		[]string{"cache", "operation"},
 	)
 
+	// ConfigStale is 1 while the application runs on cached configuration
+	// because Vault is unreachable
+	ConfigStale = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "config_stale",
+			Help: "Whether the configuration was loaded from the local secret cache instead of Vault",
+		},
+	)
+
+	// DependencyUp is 1 while the last health check of a dependency passed
+	DependencyUp = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "dependency_up",
+			Help: "Whether the last health check of a dependency passed",
+		},
+		[]string{"dependency"},
+	)
+
 	// ServiceMethodDuration tracks service method duration
 	ServiceMethodDuration = promauto.NewHistogramVec(
 		prometheus.HistogramOpts{
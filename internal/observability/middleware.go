@@ -1,16 +1,37 @@
 package observability
 
 import (
+	"io"
 	"net/http"
+	"strconv"
 	"time"
 
 	"go.opentelemetry.io/otel/attribute"
+
+	"go-spring.com/internal/server/router"
 )
 
-// MetricsMiddleware adds Prometheus metrics to HTTP requests
+// unmatchedRoute labels requests that no route handled, e.g. 404s
+const unmatchedRoute = "unmatched"
+
+// MetricsMiddleware adds Prometheus metrics to HTTP requests. Requests are
+// labeled by the template of the route the router dispatched them to.
 func MetricsMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
+		HTTPRequestsInFlight.Inc()
+		defer HTTPRequestsInFlight.Dec()
+
+		ctx, matched := router.CaptureRoute(r.Context())
+		r = r.WithContext(ctx)
+
+		// Count chunked request bodies, whose length is unknown, as the
+		// handler reads them
+		requestSize := r.ContentLength
+		body := &countingReader{ReadCloser: r.Body}
+		if requestSize < 0 && r.Body != nil && r.Body != http.NoBody {
+			r.Body = body
+		}
 
 		// Create a response writer that captures the status code
 		rw := &responseWriter{
@@ -21,9 +42,18 @@ func MetricsMiddleware(next http.Handler) http.Handler {
 		next.ServeHTTP(rw, r)
 
 		// Record metrics
-		duration := time.Since(start).Seconds()
-		HTTPRequestDuration.WithLabelValues(r.Method, r.URL.Path, string(rw.statusCode)).Observe(duration)
-		HTTPRequestsTotal.WithLabelValues(r.Method, r.URL.Path, string(rw.statusCode)).Inc()
+		route := unmatchedRoute
+		if rt := matched(); rt != nil {
+			route = rt.Pattern()
+		}
+		labels := []string{methodLabel(r.Method), route, strconv.Itoa(rw.statusCode), statusClass(rw.statusCode)}
+		HTTPRequestDuration.WithLabelValues(labels...).Observe(time.Since(start).Seconds())
+		HTTPRequestsTotal.WithLabelValues(labels...).Inc()
+		if requestSize < 0 {
+			requestSize = body.n
+		}
+		HTTPRequestSize.WithLabelValues(labels...).Observe(float64(requestSize))
+		HTTPResponseSize.WithLabelValues(labels...).Observe(float64(rw.written))
 	})
 }
 
@@ -47,8 +77,18 @@ func TracingMiddleware(tracer *Tracer) func(http.Handler) http.Handler {
 				statusCode:     http.StatusOK,
 			}
 
+			ctx, matched := router.CaptureRoute(ctx)
 			next.ServeHTTP(rw, r.WithContext(ctx))
 
+			// Name the span after the route template rather than the path
+			if rt := matched(); rt != nil {
+				span.SetName(r.Method + " " + rt.Pattern())
+				span.SetAttributes(
+					attribute.String("http.route", rt.Pattern()),
+					attribute.String("http.route_name", rt.Label()),
+				)
+			}
+
 			// Add response attributes to span
 			span.SetAttributes(
 				attribute.Int("http.status_code", rw.statusCode),
@@ -57,14 +97,61 @@ func TracingMiddleware(tracer *Tracer) func(http.Handler) http.Handler {
 	}
 }
 
-// responseWriter is a wrapper around http.ResponseWriter that captures the status code
+// methodLabel bounds the method label to the standard methods
+func methodLabel(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace:
+		return method
+	default:
+		return "OTHER"
+	}
+}
+
+// statusClass returns the class of a status code, e.g. 2xx
+func statusClass(code int) string {
+	return strconv.Itoa(code/100) + "xx"
+}
+
+// responseWriter is a wrapper around http.ResponseWriter that captures the
+// status code and the number of bytes written
 type responseWriter struct {
 	http.ResponseWriter
-	statusCode int
+	statusCode  int
+	written     int64
+	wroteHeader bool
 }
 
 // WriteHeader captures the status code
 func (rw *responseWriter) WriteHeader(code int) {
-	rw.statusCode = code
+	if !rw.wroteHeader {
+		rw.statusCode = code
+		rw.wroteHeader = true
+	}
 	rw.ResponseWriter.WriteHeader(code)
 }
+
+// Write counts the bytes written
+func (rw *responseWriter) Write(b []byte) (int, error) {
+	rw.wroteHeader = true
+	n, err := rw.ResponseWriter.Write(b)
+	rw.written += int64(n)
+	return n, err
+}
+
+// Unwrap returns the underlying writer for http.ResponseController
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
+// countingReader counts the bytes read from a request body
+type countingReader struct {
+	io.ReadCloser
+	n int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.n += int64(n)
+	return n, err
+}
@@ -71,3 +71,12 @@ func (t *Tracer) SetSpanAttributes(ctx context.Context, attrs ...attribute.KeyVa
 	span := trace.SpanFromContext(ctx)
 	span.SetAttributes(attrs...)
 }
+
+// ShutdownTracerProvider flushes and stops the global tracer provider if it
+// supports it, as the OpenTelemetry SDK provider does
+func ShutdownTracerProvider(ctx context.Context) error {
+	if tp, ok := otel.GetTracerProvider().(interface{ Shutdown(context.Context) error }); ok {
+		return tp.Shutdown(ctx)
+	}
+	return nil
+}
@@ -38,6 +38,21 @@ func NewBaseRepository(db *sql.DB, tableName string, idColumn string, columns []
 	}
 }
 
+// querier is implemented by both *sql.DB and *sql.Tx
+type querier interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// conn returns the transaction carried by ctx, or the database outside of
+// a transaction
+func (r *BaseRepository) conn(ctx context.Context) querier {
+	if tx, ok := TransactionFromContext(ctx); ok {
+		return tx.tx
+	}
+	return r.db
+}
+
 // FindByID finds an entity by its ID
 func (r *BaseRepository) FindByID(ctx context.Context, id int64) (*User, error) {
 	start := time.Now()
@@ -45,7 +60,7 @@ func (r *BaseRepository) FindByID(ctx context.Context, id int64) (*User, error)
 		joinColumns(r.columns), r.tableName, r.idColumn)
 
 	user := &User{}
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
+	err := r.conn(ctx).QueryRowContext(ctx, query, id).Scan(
 		&user.ID,
 		&user.Username,
 		&user.Email,
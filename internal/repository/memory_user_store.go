@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryUserStore keeps users in memory. It stands in for UserRepository
+// where no database is available, such as in tests.
+type MemoryUserStore struct {
+	mu     sync.RWMutex
+	users  map[int64]User
+	nextID int64
+}
+
+// NewMemoryUserStore creates an empty in-memory user store
+func NewMemoryUserStore() *MemoryUserStore {
+	return &MemoryUserStore{
+		users:  make(map[int64]User),
+		nextID: 1,
+	}
+}
+
+// FindByID finds a user by ID
+func (s *MemoryUserStore) FindByID(ctx context.Context, id int64) (*User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if user, ok := s.users[id]; ok {
+		return &user, nil
+	}
+	return nil, nil
+}
+
+// FindByUsername finds a user by username
+func (s *MemoryUserStore) FindByUsername(ctx context.Context, username string) (*User, error) {
+	return s.find(func(user *User) bool {
+		return user.Username == username
+	}), nil
+}
+
+// FindByEmail finds a user by email
+func (s *MemoryUserStore) FindByEmail(ctx context.Context, email string) (*User, error) {
+	return s.find(func(user *User) bool {
+		return user.Email == email
+	}), nil
+}
+
+// CreateUser stores a new user and assigns its ID
+func (s *MemoryUserStore) CreateUser(ctx context.Context, user *User) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	user.ID = s.nextID
+	user.CreatedAt = now
+	user.UpdatedAt = now
+	s.nextID++
+
+	s.users[user.ID] = *user
+	return nil
+}
+
+// UpdateUser replaces the stored user with the same ID
+func (s *MemoryUserStore) UpdateUser(ctx context.Context, user *User) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[user.ID]; !ok {
+		return nil
+	}
+	user.UpdatedAt = time.Now()
+	s.users[user.ID] = *user
+	return nil
+}
+
+// find returns a copy of the first user matching match
+func (s *MemoryUserStore) find(match func(user *User) bool) *User {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, user := range s.users {
+		if match(&user) {
+			return &user
+		}
+	}
+	return nil
+}
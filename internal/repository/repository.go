@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"context"
 	"fmt"
 
 	"go-spring.com/internal/config"
@@ -28,3 +29,13 @@ func (r *Repository) GetExample(args ...interface{}) (string, error) {
 	}
 	return "Example data from repository", nil
 }
+
+// UserStore is the user data access used by the service layer, implemented
+// by UserRepository and MemoryUserStore
+type UserStore interface {
+	FindByID(ctx context.Context, id int64) (*User, error)
+	FindByUsername(ctx context.Context, username string) (*User, error)
+	FindByEmail(ctx context.Context, email string) (*User, error)
+	CreateUser(ctx context.Context, user *User) error
+	UpdateUser(ctx context.Context, user *User) error
+}
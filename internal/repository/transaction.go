@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"sync"
 	"time"
 
 	"go-spring.com/internal/observability"
@@ -28,9 +29,23 @@ type Transaction struct {
 	tx     *sql.Tx
 	ctx    context.Context
 	tracer *observability.Tracer
+
+	mu          sync.Mutex
+	afterCommit []func()
 }
 
-// Begin starts a new transaction
+// txKey is the context key of the current transaction
+type txKey struct{}
+
+// TransactionFromContext returns the transaction the context was created
+// for by Begin
+func TransactionFromContext(ctx context.Context) (*Transaction, bool) {
+	tx, ok := ctx.Value(txKey{}).(*Transaction)
+	return tx, ok
+}
+
+// Begin starts a new transaction. Its context, returned by Context, carries
+// the transaction for repositories and event publishers.
 func (tm *TransactionManager) Begin(ctx context.Context) (*Transaction, error) {
 	start := time.Now()
 	tx, err := tm.db.BeginTx(ctx, &sql.TxOptions{
@@ -43,14 +58,49 @@ func (tm *TransactionManager) Begin(ctx context.Context) (*Transaction, error) {
 
 	observability.ServiceMethodDuration.WithLabelValues("Transaction", "Begin").Observe(time.Since(start).Seconds())
 
-	return &Transaction{
+	t := &Transaction{
 		tx:     tx,
-		ctx:    ctx,
 		tracer: tm.tracer,
-	}, nil
+	}
+	t.ctx = context.WithValue(ctx, txKey{}, t)
+	return t, nil
+}
+
+// InTransaction runs fn in a transaction, which is committed if fn returns
+// nil and rolled back otherwise. Repositories called with the context
+// passed to fn use the transaction. If ctx already carries a transaction,
+// fn runs in it.
+func (tm *TransactionManager) InTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := TransactionFromContext(ctx); ok {
+		return fn(ctx)
+	}
+
+	tx, err := tm.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx.Context()); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Context returns the context carrying the transaction
+func (t *Transaction) Context() context.Context {
+	return t.ctx
 }
 
-// Commit commits the transaction
+// AfterCommit registers fn to run once the transaction has committed. It
+// is discarded if the transaction rolls back.
+func (t *Transaction) AfterCommit(fn func()) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.afterCommit = append(t.afterCommit, fn)
+}
+
+// Commit commits the transaction and runs the AfterCommit callbacks
 func (t *Transaction) Commit() error {
 	start := time.Now()
 	err := t.tx.Commit()
@@ -59,12 +109,24 @@ func (t *Transaction) Commit() error {
 	}
 
 	observability.ServiceMethodDuration.WithLabelValues("Transaction", "Commit").Observe(time.Since(start).Seconds())
+
+	t.mu.Lock()
+	callbacks := t.afterCommit
+	t.afterCommit = nil
+	t.mu.Unlock()
+	for _, fn := range callbacks {
+		fn()
+	}
 	return nil
 }
 
 // Rollback rolls back the transaction
 func (t *Transaction) Rollback() error {
 	start := time.Now()
+	t.mu.Lock()
+	t.afterCommit = nil
+	t.mu.Unlock()
+
 	err := t.tx.Rollback()
 	if err != nil {
 		return fmt.Errorf("failed to rollback transaction: %w", err)
@@ -36,7 +36,7 @@ func (r *UserRepository) FindByUsername(ctx context.Context, username string) (*
 	query := "SELECT id, username, email, created_at, updated_at FROM users WHERE username = $1"
 
 	var user User
-	err := r.db.QueryRowContext(ctx, query, username).Scan(
+	err := r.conn(ctx).QueryRowContext(ctx, query, username).Scan(
 		&user.ID,
 		&user.Username,
 		&user.Email,
@@ -60,7 +60,7 @@ func (r *UserRepository) FindByEmail(ctx context.Context, email string) (*User,
 	query := "SELECT id, username, email, created_at, updated_at FROM users WHERE email = $1"
 
 	var user User
-	err := r.db.QueryRowContext(ctx, query, email).Scan(
+	err := r.conn(ctx).QueryRowContext(ctx, query, email).Scan(
 		&user.ID,
 		&user.Username,
 		&user.Email,
@@ -91,7 +91,7 @@ func (r *UserRepository) CreateUser(ctx context.Context, user *User) error {
 	user.CreatedAt = now
 	user.UpdatedAt = now
 
-	err := r.db.QueryRowContext(ctx, query,
+	err := r.conn(ctx).QueryRowContext(ctx, query,
 		user.Username,
 		user.Email,
 		user.CreatedAt,
@@ -116,7 +116,7 @@ func (r *UserRepository) UpdateUser(ctx context.Context, user *User) error {
 	`
 
 	user.UpdatedAt = time.Now()
-	_, err := r.db.ExecContext(ctx, query,
+	_, err := r.conn(ctx).ExecContext(ctx, query,
 		user.Username,
 		user.Email,
 		user.UpdatedAt,
@@ -0,0 +1,56 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/pprof"
+
+	"go-spring.com/internal/config"
+	"go-spring.com/internal/container"
+	"go-spring.com/internal/handler"
+	"go-spring.com/internal/server/middleware"
+	"go-spring.com/internal/server/router"
+)
+
+// newManagementServer creates the listener for health, metrics, profiling
+// and actuator endpoints, which the public listener does not serve
+func newManagementServer(container *container.Container, cfg *config.Config) *http.Server {
+	router := router.New()
+	container.GetHandler().RegisterManagementRoutes(router)
+
+	// Actuator endpoints describing the configuration and components
+	handler.NewActuatorHandler(container.GetConfig).
+		WithBeans(func() interface{} { return container.Beans() }, container.WriteDOT).
+		RegisterRoutes(router)
+
+	if cfg.Management.Pprof {
+		registerPprof(router)
+	}
+
+	chain := middleware.NewChain(
+		middleware.RequestID,
+		middleware.Recovery,
+	)
+
+	// Profiles and traces stream for as long as requested, so responses
+	// have no write timeout
+	return &http.Server{
+		Addr:              fmt.Sprintf("%s:%d", cfg.Management.Host, cfg.Management.Port),
+		Handler:           chain.Then(router),
+		ReadTimeout:       cfg.Server.ReadTimeout,
+		ReadHeaderTimeout: cfg.Server.ReadHeaderTimeout,
+		IdleTimeout:       cfg.Server.IdleTimeout,
+		MaxHeaderBytes:    cfg.Server.MaxHeaderBytes,
+	}
+}
+
+// registerPprof registers the net/http/pprof handlers under /debug/pprof
+func registerPprof(r *router.Router) {
+	debug := r.Group("/debug/pprof")
+	debug.Get("/cmdline", pprof.Cmdline).Name("pprof.cmdline")
+	debug.Get("/profile", pprof.Profile).Name("pprof.profile")
+	debug.Handle("", "/symbol", http.HandlerFunc(pprof.Symbol)).Name("pprof.symbol")
+	debug.Get("/trace", pprof.Trace).Name("pprof.trace")
+	// Index serves the named profiles, e.g. /debug/pprof/heap
+	debug.Get("/{profile...}", pprof.Index).Name("pprof.index")
+}
@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"net/http"
+
+	"go-spring.com/internal/server/router"
+)
+
+// Chain is an ordered list of middleware. The first middleware is the
+// outermost, so it sees the request first and the response last.
+type Chain []router.Middleware
+
+// NewChain creates a chain of middleware
+func NewChain(middleware ...router.Middleware) Chain {
+	return append(Chain(nil), middleware...)
+}
+
+// Append returns a new chain with middleware added after the existing
+// ones
+func (c Chain) Append(middleware ...router.Middleware) Chain {
+	return append(append(Chain(nil), c...), middleware...)
+}
+
+// Then wraps h in the chain's middleware
+func (c Chain) Then(h http.Handler) http.Handler {
+	for i := len(c) - 1; i >= 0; i-- {
+		h = c[i](h)
+	}
+	return h
+}
@@ -0,0 +1,318 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"go-spring.com/internal/server/router"
+)
+
+// RequestIDHeader is the header carrying the request ID
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength bounds the length of request IDs accepted from clients
+const maxRequestIDLength = 128
+
+// requestIDKey is the context key of the request ID
+type requestIDKey struct{}
+
+// RequestID takes the request ID from the X-Request-ID header or generates
+// one, echoes it in the response and stores it in the request context for
+// logs and outgoing requests
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+		w.Header().Set(RequestIDHeader, id)
+		trace.SpanFromContext(r.Context()).SetAttributes(attribute.String("http.request_id", id))
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), requestIDKey{}, id)))
+	})
+}
+
+// RequestIDFromContext returns the ID of the request being served, or ""
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
+
+// Recovery recovers from panics in handlers. It logs the panic with its
+// stack trace, marks the request's span as failed and responds with 500 if
+// nothing was written yet.
+func Recovery(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rw := &trackingWriter{ResponseWriter: w}
+		defer func() {
+			err := recover()
+			if err == nil {
+				return
+			}
+			// The server aborts the response for this value on purpose
+			if err == http.ErrAbortHandler {
+				panic(err)
+			}
+
+			log.Printf("Panic serving %s %s (request %s): %v\n%s",
+				r.Method, r.URL.Path, RequestIDFromContext(r.Context()), err, debug.Stack())
+
+			span := trace.SpanFromContext(r.Context())
+			span.RecordError(fmt.Errorf("panic: %v", err), trace.WithStackTrace(true))
+			span.SetStatus(codes.Error, "panic")
+
+			if !rw.wroteHeader {
+				http.Error(rw, "Internal server error", http.StatusInternalServerError)
+			}
+		}()
+		next.ServeHTTP(rw, r)
+	})
+}
+
+// Timeout cancels the request context after d and responds with 503 at
+// that moment. Like http.TimeoutHandler, the handler runs in its own
+// goroutine and its response is buffered until it returns, so it cannot
+// stream or flush, and whatever it writes after the deadline is discarded.
+// Handlers must honor the context to stop early. A Timeout further down the
+// chain replaces this one instead of nesting inside it, so a route can set
+// a shorter or longer timeout than the server default with router.With.
+func Timeout(d time.Duration) router.Middleware {
+	return func(next http.Handler) http.Handler {
+		if d <= 0 {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			base := r.Context()
+			if enclosing, ok := base.Value(timeoutKey{}).(*timeoutScope); ok && enclosing.replace() {
+				base = enclosing.base
+			}
+			deadline, cancel := context.WithTimeout(base, d)
+			defer cancel()
+
+			ctx := deadline
+			if base != r.Context() {
+				ctx = deadlineContext{Context: r.Context(), deadline: deadline}
+			}
+			scope := &timeoutScope{base: base}
+			ctx = context.WithValue(ctx, timeoutKey{}, scope)
+			serveWithTimeout(w, r.WithContext(ctx), next, scope)
+		})
+	}
+}
+
+// serveWithTimeout runs next in its own goroutine and copies its response
+// to w, or responds with 503 once the deadline of scope has passed
+func serveWithTimeout(w http.ResponseWriter, r *http.Request, next http.Handler, scope *timeoutScope) {
+	tw := &timeoutWriter{header: make(http.Header), code: http.StatusOK}
+	done := make(chan struct{})
+	panicked := make(chan interface{}, 1)
+	go func() {
+		defer func() {
+			if err := recover(); err != nil {
+				// Keep the handler's stack, Recovery only sees this goroutine's
+				if err != http.ErrAbortHandler {
+					err = fmt.Sprintf("%v\n\n%s", err, debug.Stack())
+				}
+				panicked <- err
+				return
+			}
+			close(done)
+		}()
+		next.ServeHTTP(tw, r)
+	}()
+
+	select {
+	case err := <-panicked:
+		panic(err)
+	case <-done:
+		tw.copyTo(w)
+		return
+	case <-r.Context().Done():
+	}
+
+	// Wait for handlers whose client went away or whose deadline was
+	// replaced by a nested Timeout
+	if !errors.Is(r.Context().Err(), context.DeadlineExceeded) || !scope.expire() {
+		select {
+		case err := <-panicked:
+			panic(err)
+		case <-done:
+			tw.copyTo(w)
+		}
+		return
+	}
+
+	tw.mu.Lock()
+	tw.timedOut = true
+	tw.mu.Unlock()
+	http.Error(w, "Request timed out", http.StatusServiceUnavailable)
+}
+
+// timeoutKey is the context key of the innermost Timeout
+type timeoutKey struct{}
+
+// timeoutScope is the deadline enforced by a Timeout until a nested
+// Timeout replaces it
+type timeoutScope struct {
+	// base is the request context without the deadline
+	base context.Context
+
+	mu       sync.Mutex
+	replaced bool
+	expired  bool
+}
+
+// replace hands the deadline over to a nested Timeout, unless it has
+// already passed
+func (s *timeoutScope) replace() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.replaced = !s.expired
+	return s.replaced
+}
+
+// expire reports whether the deadline still applies once it has passed
+func (s *timeoutScope) expire() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.expired = !s.replaced
+	return s.expired
+}
+
+// deadlineContext carries the values of the request context with the
+// deadline and cancellation of a replacing Timeout
+type deadlineContext struct {
+	context.Context
+	deadline context.Context
+}
+
+func (c deadlineContext) Deadline() (time.Time, bool) {
+	return c.deadline.Deadline()
+}
+
+func (c deadlineContext) Done() <-chan struct{} {
+	return c.deadline.Done()
+}
+
+func (c deadlineContext) Err() error {
+	return c.deadline.Err()
+}
+
+// BodyLimit rejects request bodies larger than n bytes with 413. Bodies
+// without a declared length are cut off after n bytes, failing the
+// handler's read.
+func BodyLimit(n int64) router.Middleware {
+	return func(next http.Handler) http.Handler {
+		if n <= 0 {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.ContentLength > n {
+				http.Error(w, "Request body too large", http.StatusRequestEntityTooLarge)
+				return
+			}
+			r.Body = http.MaxBytesReader(w, r.Body, n)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// trackingWriter records whether the response has been started
+type trackingWriter struct {
+	http.ResponseWriter
+	wroteHeader bool
+}
+
+func (w *trackingWriter) WriteHeader(code int) {
+	w.wroteHeader = true
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *trackingWriter) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *trackingWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// timeoutWriter buffers the response of a handler run by Timeout
+type timeoutWriter struct {
+	header http.Header
+	body   bytes.Buffer
+	code   int
+
+	mu          sync.Mutex
+	wroteHeader bool
+	timedOut    bool
+}
+
+// errTimedOut is returned to handlers writing after the deadline
+var errTimedOut = errors.New("handler timed out")
+
+func (w *timeoutWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *timeoutWriter) WriteHeader(code int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.timedOut || w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+	w.code = code
+}
+
+func (w *timeoutWriter) Write(b []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.timedOut {
+		return 0, errTimedOut
+	}
+	w.wroteHeader = true
+	return w.body.Write(b)
+}
+
+// copyTo writes the buffered response to dst once the handler returned
+func (w *timeoutWriter) copyTo(dst http.ResponseWriter) {
+	header := dst.Header()
+	for key, values := range w.header {
+		header[key] = values
+	}
+	dst.WriteHeader(w.code)
+	dst.Write(w.body.Bytes())
+}
@@ -0,0 +1,106 @@
+package router
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+)
+
+// ErrMissingParam is returned for a parameter that is not present
+var ErrMissingParam = errors.New("missing parameter")
+
+// ParamType lists the types path and query parameters can be parsed into
+type ParamType interface {
+	~string | ~bool | ~int | ~int32 | ~int64 | ~uint | ~uint32 | ~uint64 | ~float64
+}
+
+// ParamError reports a path or query parameter that is missing or cannot
+// be parsed
+type ParamError struct {
+	In    string // "path" or "query"
+	Name  string
+	Value string
+	Err   error
+}
+
+func (e *ParamError) Error() string {
+	if errors.Is(e.Err, ErrMissingParam) {
+		return fmt.Sprintf("missing %s parameter %s", e.In, e.Name)
+	}
+	return fmt.Sprintf("invalid %s parameter %s=%q: %v", e.In, e.Name, e.Value, e.Err)
+}
+
+func (e *ParamError) Unwrap() error {
+	return e.Err
+}
+
+// Param returns the raw value of a path parameter of the current route
+func Param(r *http.Request, name string) string {
+	if m, ok := r.Context().Value(matchKey{}).(*match); ok {
+		return m.params[name]
+	}
+	return ""
+}
+
+// PathParam parses a path parameter of the current route as T
+func PathParam[T ParamType](r *http.Request, name string) (T, error) {
+	return parseParam[T]("path", name, Param(r, name))
+}
+
+// QueryParam parses a query parameter as T. A missing or empty parameter
+// is an error wrapping ErrMissingParam.
+func QueryParam[T ParamType](r *http.Request, name string) (T, error) {
+	return parseParam[T]("query", name, r.URL.Query().Get(name))
+}
+
+// QueryParamOr parses a query parameter as T, returning def if it is
+// missing or empty
+func QueryParamOr[T ParamType](r *http.Request, name string, def T) (T, error) {
+	if r.URL.Query().Get(name) == "" {
+		return def, nil
+	}
+	return QueryParam[T](r, name)
+}
+
+func parseParam[T ParamType](in, name, raw string) (T, error) {
+	var value T
+	if raw == "" {
+		return value, &ParamError{In: in, Name: name, Err: ErrMissingParam}
+	}
+
+	v := reflect.ValueOf(&value).Elem()
+	var err error
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(raw)
+	case reflect.Bool:
+		var b bool
+		if b, err = strconv.ParseBool(raw); err == nil {
+			v.SetBool(b)
+		}
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		var n int64
+		if n, err = strconv.ParseInt(raw, 10, v.Type().Bits()); err == nil {
+			v.SetInt(n)
+		}
+	case reflect.Uint, reflect.Uint32, reflect.Uint64:
+		var n uint64
+		if n, err = strconv.ParseUint(raw, 10, v.Type().Bits()); err == nil {
+			v.SetUint(n)
+		}
+	case reflect.Float64:
+		var f float64
+		if f, err = strconv.ParseFloat(raw, 64); err == nil {
+			v.SetFloat(f)
+		}
+	}
+	if err != nil {
+		if numErr, ok := err.(*strconv.NumError); ok {
+			err = numErr.Err
+		}
+		return value, &ParamError{In: in, Name: name, Value: raw, Err: err}
+	}
+	return value, nil
+}
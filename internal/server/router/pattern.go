@@ -0,0 +1,101 @@
+package router
+
+import (
+	"fmt"
+	"strings"
+)
+
+// segmentKind orders segments by precedence, literals first
+type segmentKind int
+
+const (
+	literal segmentKind = iota
+	param
+	wildcard
+)
+
+type segment struct {
+	kind  segmentKind
+	value string // the literal text or the parameter name
+}
+
+// parsePattern splits a pattern into its segments
+func parsePattern(pattern string) ([]segment, error) {
+	if !strings.HasPrefix(pattern, "/") {
+		return nil, fmt.Errorf("pattern %q must start with /", pattern)
+	}
+	parts := strings.Split(pattern[1:], "/")
+	segments := make([]segment, len(parts))
+	seen := make(map[string]bool)
+	for i, part := range parts {
+		if !strings.HasPrefix(part, "{") || !strings.HasSuffix(part, "}") {
+			if strings.ContainsAny(part, "{}") {
+				return nil, fmt.Errorf("pattern %q: invalid segment %q", pattern, part)
+			}
+			segments[i] = segment{kind: literal, value: part}
+			continue
+		}
+
+		name, kind := part[1:len(part)-1], param
+		if strings.HasSuffix(name, "...") {
+			if i != len(parts)-1 {
+				return nil, fmt.Errorf("pattern %q: %s must be the last segment", pattern, part)
+			}
+			name, kind = strings.TrimSuffix(name, "..."), wildcard
+		}
+		if name == "" || strings.ContainsAny(name, "{}") {
+			return nil, fmt.Errorf("pattern %q: invalid parameter %q", pattern, part)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("pattern %q: duplicate parameter %q", pattern, name)
+		}
+		seen[name] = true
+		segments[i] = segment{kind: kind, value: name}
+	}
+	return segments, nil
+}
+
+// matchSegments matches path against segments and returns the parameter
+// values
+func matchSegments(segments []segment, path string) (map[string]string, bool) {
+	if !strings.HasPrefix(path, "/") {
+		return nil, false
+	}
+	parts := strings.Split(path[1:], "/")
+	params := make(map[string]string)
+	for i, seg := range segments {
+		if seg.kind == wildcard {
+			params[seg.value] = strings.Join(parts[i:], "/")
+			return params, true
+		}
+		if i >= len(parts) {
+			return nil, false
+		}
+		switch seg.kind {
+		case literal:
+			if parts[i] != seg.value {
+				return nil, false
+			}
+		case param:
+			if parts[i] == "" {
+				return nil, false
+			}
+			params[seg.value] = parts[i]
+		}
+	}
+	if len(parts) != len(segments) {
+		return nil, false
+	}
+	return params, true
+}
+
+// moreSpecific reports whether a takes precedence over b, comparing
+// segment kinds from the left
+func moreSpecific(a, b []segment) bool {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i].kind != b[i].kind {
+			return a[i].kind < b[i].kind
+		}
+	}
+	return len(a) > len(b)
+}
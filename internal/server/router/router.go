@@ -0,0 +1,263 @@
+package router
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// Middleware wraps a handler
+type Middleware func(http.Handler) http.Handler
+
+// Router dispatches requests by method and path pattern. Patterns are made
+// of literal segments and {name} parameters, e.g. /api/users/{id}; a last
+// segment {name...} matches the rest of the path. When several patterns
+// match, literal segments take precedence over parameters. A path matching
+// only routes for other methods is answered with 405 and an Allow header.
+type Router struct {
+	prefix     string
+	middleware []Middleware
+	table      *table
+}
+
+// Route is a registered method and pattern
+type Route struct {
+	method   string
+	pattern  string
+	name     string
+	segments []segment
+	handler  http.Handler
+	table    *table
+}
+
+// table holds the routes shared by a router and its groups
+type table struct {
+	routes []*Route
+	names  map[string]*Route
+}
+
+// matchKey is the context key of the matched route and its parameters
+type matchKey struct{}
+
+type match struct {
+	route  *Route
+	params map[string]string
+}
+
+// captureKey is the context key of the slot set by CaptureRoute
+type captureKey struct{}
+
+// CaptureRoute returns a context in which the router records the route it
+// dispatches to, and a function returning that route or nil if no route
+// matched. Middleware wrapping the router use it to label requests by
+// route template. Nested calls share the slot of the outermost one.
+func CaptureRoute(ctx context.Context) (context.Context, func() *Route) {
+	slot, ok := ctx.Value(captureKey{}).(**Route)
+	if !ok {
+		slot = new(*Route)
+		ctx = context.WithValue(ctx, captureKey{}, slot)
+	}
+	return ctx, func() *Route {
+		return *slot
+	}
+}
+
+// New creates a router without routes
+func New() *Router {
+	return &Router{table: &table{names: make(map[string]*Route)}}
+}
+
+// Group returns a router registering routes under prefix on the same
+// table. Its routes run the middleware of r followed by middleware.
+func (r *Router) Group(prefix string, middleware ...Middleware) *Router {
+	return &Router{
+		prefix:     r.prefix + prefix,
+		middleware: append(append([]Middleware(nil), r.middleware...), middleware...),
+		table:      r.table,
+	}
+}
+
+// With returns a router registering routes with the same prefix that run
+// additional middleware, e.g. r.With(middleware.Timeout(time.Second))
+func (r *Router) With(middleware ...Middleware) *Router {
+	return r.Group("", middleware...)
+}
+
+// Use adds middleware to the routes registered on r afterwards
+func (r *Router) Use(middleware ...Middleware) {
+	r.middleware = append(r.middleware, middleware...)
+}
+
+// Get registers a handler for GET requests, which also serves HEAD
+func (r *Router) Get(pattern string, h http.HandlerFunc) *Route {
+	return r.Handle(http.MethodGet, pattern, h)
+}
+
+// Post registers a handler for POST requests
+func (r *Router) Post(pattern string, h http.HandlerFunc) *Route {
+	return r.Handle(http.MethodPost, pattern, h)
+}
+
+// Put registers a handler for PUT requests
+func (r *Router) Put(pattern string, h http.HandlerFunc) *Route {
+	return r.Handle(http.MethodPut, pattern, h)
+}
+
+// Patch registers a handler for PATCH requests
+func (r *Router) Patch(pattern string, h http.HandlerFunc) *Route {
+	return r.Handle(http.MethodPatch, pattern, h)
+}
+
+// Delete registers a handler for DELETE requests
+func (r *Router) Delete(pattern string, h http.HandlerFunc) *Route {
+	return r.Handle(http.MethodDelete, pattern, h)
+}
+
+// Handle registers h for method and the pattern under the router's
+// prefix. An empty method matches every method. It panics if the pattern
+// is invalid or already registered for the method.
+func (r *Router) Handle(method, pattern string, h http.Handler) *Route {
+	full := r.prefix + pattern
+	segments, err := parsePattern(full)
+	if err != nil {
+		panic(fmt.Sprintf("router: %v", err))
+	}
+	for _, existing := range r.table.routes {
+		if existing.method == method && existing.pattern == full {
+			panic(fmt.Sprintf("router: duplicate route %s", existing))
+		}
+	}
+
+	for i := len(r.middleware) - 1; i >= 0; i-- {
+		h = r.middleware[i](h)
+	}
+	route := &Route{
+		method:   method,
+		pattern:  full,
+		segments: segments,
+		handler:  h,
+		table:    r.table,
+	}
+	r.table.routes = append(r.table.routes, route)
+	return route
+}
+
+// Name names the route, e.g. for metrics. It panics if the name is taken.
+func (rt *Route) Name(name string) *Route {
+	if existing, ok := rt.table.names[name]; ok {
+		panic(fmt.Sprintf("router: route name %q already used by %s", name, existing))
+	}
+	rt.name = name
+	rt.table.names[name] = rt
+	return rt
+}
+
+// String returns the method and pattern of the route
+func (rt *Route) String() string {
+	if rt.method == "" {
+		return rt.pattern
+	}
+	return rt.method + " " + rt.pattern
+}
+
+// Pattern returns the full pattern of the route
+func (rt *Route) Pattern() string {
+	return rt.pattern
+}
+
+// Label identifies the route in traces, as the http.route_name span
+// attribute: its name if it has one, otherwise its pattern
+func (rt *Route) Label() string {
+	if rt.name != "" {
+		return rt.name
+	}
+	return rt.pattern
+}
+
+// ServeHTTP dispatches the request to the most specific route for its
+// path and method
+func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	candidates, params := r.table.match(req.URL.Path)
+	if len(candidates) == 0 {
+		http.NotFound(w, req)
+		return
+	}
+
+	for i, route := range candidates {
+		if route.allows(req.Method) {
+			if slot, ok := req.Context().Value(captureKey{}).(**Route); ok {
+				*slot = route
+			}
+			ctx := context.WithValue(req.Context(), matchKey{}, &match{route: route, params: params[i]})
+			route.handler.ServeHTTP(w, req.WithContext(ctx))
+			return
+		}
+	}
+
+	w.Header().Set("Allow", allowed(candidates))
+	if req.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+}
+
+// CurrentRoute returns the route serving the request, or nil outside of
+// a route handler
+func CurrentRoute(r *http.Request) *Route {
+	if m, ok := r.Context().Value(matchKey{}).(*match); ok {
+		return m.route
+	}
+	return nil
+}
+
+func (rt *Route) allows(method string) bool {
+	return rt.method == "" || rt.method == method ||
+		(method == http.MethodHead && rt.method == http.MethodGet)
+}
+
+// match returns the routes matching path, most specific first, with the
+// parameters of each
+func (t *table) match(path string) ([]*Route, []map[string]string) {
+	var routes []*Route
+	var params []map[string]string
+	for _, route := range t.routes {
+		if p, ok := matchSegments(route.segments, path); ok {
+			routes = append(routes, route)
+			params = append(params, p)
+		}
+	}
+
+	order := make([]int, len(routes))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return moreSpecific(routes[order[i]].segments, routes[order[j]].segments)
+	})
+	sortedRoutes := make([]*Route, len(routes))
+	sortedParams := make([]map[string]string, len(routes))
+	for i, k := range order {
+		sortedRoutes[i], sortedParams[i] = routes[k], params[k]
+	}
+	return sortedRoutes, sortedParams
+}
+
+// allowed returns the Allow header for the matching routes
+func allowed(routes []*Route) string {
+	methods := map[string]bool{http.MethodOptions: true}
+	for _, route := range routes {
+		methods[route.method] = true
+		if route.method == http.MethodGet {
+			methods[http.MethodHead] = true
+		}
+	}
+	list := make([]string, 0, len(methods))
+	for method := range methods {
+		list = append(list, method)
+	}
+	sort.Strings(list)
+	return strings.Join(list, ", ")
+}
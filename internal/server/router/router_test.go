@@ -0,0 +1,119 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// echo responds with the route pattern and the given parameters
+func echo(names ...string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, CurrentRoute(r).Pattern())
+		for _, name := range names {
+			fmt.Fprintf(w, " %s=%s", name, Param(r, name))
+		}
+	}
+}
+
+func TestRouterDispatch(t *testing.T) {
+	r := New()
+	r.Get("/users/{id}", echo("id"))
+	r.Get("/users/me", echo())
+	r.Post("/users", echo())
+	r.Get("/files/{path...}", echo("path"))
+	r.Get("/users/{id}/posts/{post}", echo("id", "post"))
+	r.Handle("", "/any", echo())
+	api := r.Group("/api", func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			w.Header().Set("X-Group", "api")
+			next.ServeHTTP(w, req)
+		})
+	})
+	api.Get("/items/{id}", echo("id"))
+
+	tests := []struct {
+		name      string
+		method    string
+		path      string
+		wantCode  int
+		wantBody  string
+		wantAllow string
+		wantGroup string
+	}{
+		{name: "literal over parameter", method: http.MethodGet, path: "/users/me", wantCode: http.StatusOK, wantBody: "/users/me"},
+		{name: "parameter", method: http.MethodGet, path: "/users/42", wantCode: http.StatusOK, wantBody: "/users/{id} id=42"},
+		{name: "several parameters", method: http.MethodGet, path: "/users/42/posts/7", wantCode: http.StatusOK, wantBody: "/users/{id}/posts/{post} id=42 post=7"},
+		{name: "wildcard", method: http.MethodGet, path: "/files/a/b.txt", wantCode: http.StatusOK, wantBody: "/files/{path...} path=a/b.txt"},
+		{name: "HEAD served by GET", method: http.MethodHead, path: "/users/42", wantCode: http.StatusOK},
+		{name: "any method", method: http.MethodDelete, path: "/any", wantCode: http.StatusOK, wantBody: "/any"},
+		{name: "group prefix and middleware", method: http.MethodGet, path: "/api/items/3", wantCode: http.StatusOK, wantBody: "/api/items/{id} id=3", wantGroup: "api"},
+		{name: "not found", method: http.MethodGet, path: "/missing", wantCode: http.StatusNotFound},
+		{name: "group route without prefix", method: http.MethodGet, path: "/items/3", wantCode: http.StatusNotFound},
+		{name: "method not allowed", method: http.MethodDelete, path: "/users", wantCode: http.StatusMethodNotAllowed, wantAllow: "OPTIONS, POST"},
+		{name: "method not allowed lists HEAD", method: http.MethodPut, path: "/users/42", wantCode: http.StatusMethodNotAllowed, wantAllow: "GET, HEAD, OPTIONS"},
+		{name: "OPTIONS", method: http.MethodOptions, path: "/users", wantCode: http.StatusNoContent, wantAllow: "OPTIONS, POST"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, nil))
+			if rec.Code != tt.wantCode {
+				t.Errorf("status: got %d, want %d", rec.Code, tt.wantCode)
+			}
+			if tt.wantBody != "" && rec.Body.String() != tt.wantBody {
+				t.Errorf("body: got %q, want %q", rec.Body, tt.wantBody)
+			}
+			if got := rec.Header().Get("Allow"); got != tt.wantAllow {
+				t.Errorf("Allow: got %q, want %q", got, tt.wantAllow)
+			}
+			if got := rec.Header().Get("X-Group"); got != tt.wantGroup {
+				t.Errorf("group middleware: got %q, want %q", got, tt.wantGroup)
+			}
+		})
+	}
+}
+
+func TestRouterPanicsOnInvalidRoutes(t *testing.T) {
+	tests := []struct {
+		name     string
+		register func(r *Router)
+	}{
+		{name: "duplicate route", register: func(r *Router) {
+			r.Get("/users", echo())
+			r.Get("/users", echo())
+		}},
+		{name: "duplicate name", register: func(r *Router) {
+			r.Get("/users", echo()).Name("users")
+			r.Post("/users", echo()).Name("users")
+		}},
+		{name: "wildcard not last", register: func(r *Router) {
+			r.Get("/files/{path...}/raw", echo())
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("got no panic")
+				}
+			}()
+			tt.register(New())
+		})
+	}
+}
+
+func TestCaptureRoute(t *testing.T) {
+	r := New()
+	r.Get("/users/{id}", echo()).Name("user")
+
+	ctx, route := CaptureRoute(httptest.NewRequest(http.MethodGet, "/", nil).Context())
+	req := httptest.NewRequest(http.MethodGet, "/users/1", nil).WithContext(ctx)
+	r.ServeHTTP(httptest.NewRecorder(), req)
+	if got := route(); got == nil || got.Label() != "user" {
+		t.Errorf("captured route: got %v, want user", got)
+	}
+}
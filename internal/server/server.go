@@ -2,60 +2,147 @@ package server
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log"
 	"net/http"
 	"time"
 
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+
+	"go-spring.com/internal/config"
 	"go-spring.com/internal/container"
-	"go-spring.com/internal/handler"
 	"go-spring.com/internal/observability"
+	"go-spring.com/internal/server/middleware"
+	"go-spring.com/internal/server/router"
 )
 
 // Server represents the HTTP server
 type Server struct {
-	container *container.Container
-	server    *http.Server
-	tracer    *observability.Tracer
+	container  *container.Container
+	server     *http.Server
+	management *http.Server
+	tracer     *observability.Tracer
+
+	// tls is loaded when the server starts and its files are checked for
+	// changes every reloadInterval
+	tls            config.TLSConfig
+	reloadInterval time.Duration
 }
 
 // NewServer creates a new HTTP server
 func NewServer(container *container.Container) *Server {
 	cfg := container.GetConfig()
 
-	// Create router and register routes
-	router := http.NewServeMux()
+	// The tracer provider is shut down by main after the container
+	tracer := container.GetTracer()
 
-	// Initialize tracer
-	tracer := observability.NewTracer("go-spring")
+	// Create router and register routes
+	router := router.New()
+	container.GetHandler().RegisterRoutes(router)
 
-	// Add observability middleware
-	router = http.NewServeMux()
-	handler.NewHandler(container.GetUserService()).RegisterRoutes(router)
+	// Recovery runs inside metrics and tracing so that panics are
+	// recorded as 500s on the request's span
+	chain := middleware.NewChain(
+		observability.MetricsMiddleware,
+		observability.TracingMiddleware(tracer),
+		middleware.RequestID,
+		middleware.Recovery,
+		middleware.Timeout(cfg.Server.HandlerTimeout),
+		middleware.BodyLimit(cfg.Server.MaxBodyBytes),
+		container.RequestScope,
+	)
 
 	// Create HTTP server with middleware
+	public := chain.Then(router)
+	if cfg.Server.H2C {
+		public = h2c.NewHandler(public, &http2.Server{IdleTimeout: cfg.Server.IdleTimeout})
+	}
 	server := &http.Server{
-		Addr: fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.Port),
-		Handler: observability.MetricsMiddleware(
-			observability.TracingMiddleware(tracer)(router),
-		),
-		ReadTimeout:  15 * time.Second,
-		WriteTimeout: 15 * time.Second,
-		IdleTimeout:  60 * time.Second,
+		Addr:              fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.Port),
+		Handler:           public,
+		ReadTimeout:       cfg.Server.ReadTimeout,
+		ReadHeaderTimeout: cfg.Server.ReadHeaderTimeout,
+		WriteTimeout:      cfg.Server.WriteTimeout,
+		IdleTimeout:       cfg.Server.IdleTimeout,
+		MaxHeaderBytes:    cfg.Server.MaxHeaderBytes,
 	}
 
-	return &Server{
-		container: container,
-		server:    server,
-		tracer:    tracer,
+	s := &Server{
+		container:      container,
+		server:         server,
+		management:     newManagementServer(container, cfg),
+		tracer:         tracer,
+		tls:            cfg.Server.TLS,
+		reloadInterval: cfg.Reload.Interval,
 	}
+	container.GetConfigWatcher().Subscribe(config.SubscriberFunc(s.onConfigChange))
+
+	return s
+}
+
+// onConfigChange reports listener settings that only apply after a restart
+func (s *Server) onConfigChange(ctx context.Context, change *config.Change) error {
+	if change.Changed("server.host", "server.port") {
+		log.Printf("Server address changed to %s:%d, restart required to apply",
+			change.New.Server.Host, change.New.Server.Port)
+	} else if change.Changed("server", "management") {
+		log.Println("Server settings changed, restart required to apply")
+	}
+	return nil
+}
+
+// Addr returns the address the server listens on
+func (s *Server) Addr() string {
+	return s.server.Addr
 }
 
-// Start starts the HTTP server
+// ManagementAddr returns the address of the management listener
+func (s *Server) ManagementAddr() string {
+	return s.management.Addr
+}
+
+// Handler returns the server's handler with all routes and middleware, for
+// serving requests without listening, e.g. with httptest
+func (s *Server) Handler() http.Handler {
+	return s.server.Handler
+}
+
+// ManagementHandler returns the handler of the management listener
+func (s *Server) ManagementHandler() http.Handler {
+	return s.management.Handler
+}
+
+// Start starts the public and management listeners and returns when
+// either of them stops
 func (s *Server) Start() error {
-	return s.server.ListenAndServe()
+	errs := make(chan error, 2)
+	go func() {
+		errs <- s.management.ListenAndServe()
+	}()
+	go func() {
+		errs <- s.serve()
+	}()
+	return <-errs
+}
+
+// serve runs the public listener, serving HTTPS when TLS is configured
+func (s *Server) serve() error {
+	if !s.tls.Enabled() {
+		return s.server.ListenAndServe()
+	}
+
+	tlsConfig, err := newTLSConfig(s.tls, s.reloadInterval)
+	if err != nil {
+		return err
+	}
+	s.server.TLSConfig = tlsConfig
+	return s.server.ListenAndServeTLS("", "")
 }
 
-// Shutdown gracefully shuts down the server
+// Shutdown gracefully shuts down the public listener, then the management
+// listener so that health and metrics stay available while requests drain
 func (s *Server) Shutdown(ctx context.Context) error {
-	return s.server.Shutdown(ctx)
+	return errors.Join(s.server.Shutdown(ctx), s.management.Shutdown(ctx))
 }
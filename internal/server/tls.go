@@ -0,0 +1,149 @@
+package server
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"go-spring.com/internal/config"
+)
+
+// tlsVersions maps the configured minimum version to its constant
+var tlsVersions = map[string]uint16{
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// clientAuthTypes maps the configured client authentication mode
+var clientAuthTypes = map[string]tls.ClientAuthType{
+	"none":     tls.NoClientCert,
+	"optional": tls.VerifyClientCertIfGiven,
+	"require":  tls.RequireAndVerifyClientCert,
+}
+
+// certReloader serves the certificate and client CAs from files, loading
+// them again when their modification time changes. Files are checked on
+// handshakes at most once per interval; a zero interval disables reloading.
+type certReloader struct {
+	cfg      config.TLSConfig
+	interval time.Duration
+
+	mu        sync.Mutex
+	cert      *tls.Certificate
+	clientCAs *x509.CertPool
+	modTimes  map[string]time.Time
+	checked   time.Time
+}
+
+// newTLSConfig loads the certificate and client CAs and returns the
+// server's TLS configuration
+func newTLSConfig(cfg config.TLSConfig, interval time.Duration) (*tls.Config, error) {
+	r := &certReloader{cfg: cfg, interval: interval}
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+
+	base := &tls.Config{
+		MinVersion:     tlsVersions[cfg.MinVersion],
+		ClientAuth:     clientAuthTypes[cfg.ClientAuth],
+		NextProtos:     []string{"h2", "http/1.1"},
+		GetCertificate: r.getCertificate,
+	}
+	if cfg.ClientCAFile != "" {
+		// The CA pool is a field rather than a callback, so every
+		// handshake gets a copy of the configuration with the current pool
+		base.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			c := base.Clone()
+			c.GetConfigForClient = nil
+			c.ClientCAs = r.currentClientCAs()
+			return c, nil
+		}
+	}
+	return base, nil
+}
+
+func (r *certReloader) getCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.reloadIfChanged()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.cert, nil
+}
+
+func (r *certReloader) currentClientCAs() *x509.CertPool {
+	r.reloadIfChanged()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.clientCAs
+}
+
+// reloadIfChanged reloads the files if the interval has passed and one of
+// them was modified. On failure the previous files stay in use.
+func (r *certReloader) reloadIfChanged() {
+	if r.interval <= 0 {
+		return
+	}
+	r.mu.Lock()
+	if time.Since(r.checked) < r.interval {
+		r.mu.Unlock()
+		return
+	}
+	r.checked = time.Now()
+	changed := false
+	for file, modTime := range r.modTimes {
+		if info, err := os.Stat(file); err == nil && !info.ModTime().Equal(modTime) {
+			changed = true
+		}
+	}
+	r.mu.Unlock()
+
+	if !changed {
+		return
+	}
+	if err := r.load(); err != nil {
+		log.Printf("Failed to reload TLS certificates, keeping the current ones: %v", err)
+		return
+	}
+	log.Println("TLS certificates reloaded")
+}
+
+// load reads the certificate, key and client CAs
+func (r *certReloader) load() error {
+	files := []string{r.cfg.CertFile, r.cfg.KeyFile}
+	if r.cfg.ClientCAFile != "" {
+		files = append(files, r.cfg.ClientCAFile)
+	}
+	// Stat before reading so that a change during the read is seen again
+	modTimes := make(map[string]time.Time, len(files))
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			return fmt.Errorf("failed to read TLS file: %w", err)
+		}
+		modTimes[file] = info.ModTime()
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.cfg.CertFile, r.cfg.KeyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load TLS certificate: %w", err)
+	}
+	var clientCAs *x509.CertPool
+	if r.cfg.ClientCAFile != "" {
+		pem, err := os.ReadFile(r.cfg.ClientCAFile)
+		if err != nil {
+			return fmt.Errorf("failed to read client CA file: %w", err)
+		}
+		clientCAs = x509.NewCertPool()
+		if !clientCAs.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("no certificates found in client CA file %s", r.cfg.ClientCAFile)
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cert, r.clientCAs, r.modTimes = &cert, clientCAs, modTimes
+	return nil
+}
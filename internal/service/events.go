@@ -0,0 +1,13 @@
+package service
+
+import "go-spring.com/internal/repository"
+
+// UserCreated is published after a user has been created
+type UserCreated struct {
+	User *repository.User
+}
+
+// UserUpdated is published after a user has been updated
+type UserUpdated struct {
+	User *repository.User
+}
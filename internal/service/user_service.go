@@ -3,25 +3,30 @@ package service
 import (
 	"context"
 	"fmt"
+	"log"
 	"time"
 
 	"go-spring.com/internal/cache"
+	"go-spring.com/internal/event"
 	"go-spring.com/internal/observability"
 	"go-spring.com/internal/repository"
 )
 
 // UserService handles user-related business logic
 type UserService struct {
-	userRepo *repository.UserRepository
+	userRepo repository.UserStore
 	cache    cache.Cache
+	events   *event.Bus
 	tracer   *observability.Tracer
 }
 
-// NewUserService creates a new user service
-func NewUserService(userRepo *repository.UserRepository, cache cache.Cache) *UserService {
+// NewUserService creates a new user service. It publishes UserCreated and
+// UserUpdated to events.
+func NewUserService(userRepo repository.UserStore, cache cache.Cache, events *event.Bus) *UserService {
 	return &UserService{
 		userRepo: userRepo,
 		cache:    cache,
+		events:   events,
 		tracer:   observability.NewTracer("user_service"),
 	}
 }
@@ -38,12 +43,12 @@ func (s *UserService) GetUserByID(ctx context.Context, id int64) (*repository.Us
 	cacheKey := fmt.Sprintf("user:%d", id)
 	if cached, err := s.cache.Get(ctx, cacheKey); err {
 		if user, ok := cached.(*repository.User); ok {
-			observability.CacheHits.WithLabelValues().Inc()
+			observability.CacheHits.WithLabelValues("user", "GetUserByID").Inc()
 			observability.ServiceMethodDuration.WithLabelValues("UserService", "GetUserByID").Observe(time.Since(start).Seconds())
 			return user, nil
 		}
 	}
-	observability.CacheMisses.WithLabelValues().Inc()
+	observability.CacheMisses.WithLabelValues("user", "GetUserByID").Inc()
 
 	// Get from database
 	user, err := observability.TraceFunctionWithResult(s.tracer, ctx, "GetUserByID", func(ctx context.Context) (*repository.User, error) {
@@ -70,12 +75,12 @@ func (s *UserService) GetUserByUsername(ctx context.Context, username string) (*
 	cacheKey := fmt.Sprintf("user:username:%s", username)
 	if cached, err := s.cache.Get(ctx, cacheKey); err {
 		if user, ok := cached.(*repository.User); ok {
-			observability.CacheHits.WithLabelValues().Inc()
+			observability.CacheHits.WithLabelValues("user", "GetUserByUsername").Inc()
 			observability.ServiceMethodDuration.WithLabelValues("UserService", "GetUserByUsername").Observe(time.Since(start).Seconds())
 			return user, nil
 		}
 	}
-	observability.CacheMisses.WithLabelValues().Inc()
+	observability.CacheMisses.WithLabelValues("user", "GetUserByUsername").Inc()
 
 	// Get from database
 	user, err := observability.TraceFunctionWithResult(s.tracer, ctx, "GetUserByUsername", func(ctx context.Context) (*repository.User, error) {
@@ -128,6 +133,11 @@ func (s *UserService) CreateUser(ctx context.Context, user *repository.User) err
 	s.cache.Delete(ctx, fmt.Sprintf("user:%d", user.ID))
 	s.cache.Delete(ctx, fmt.Sprintf("user:username:%s", user.Username))
 
+	// The user exists at this point, so listener errors do not fail the call
+	if err := s.events.PublishAfterCommit(ctx, UserCreated{User: user}); err != nil {
+		log.Printf("Failed to publish UserCreated: %v", err)
+	}
+
 	observability.ServiceMethodDuration.WithLabelValues("UserService", "CreateUser").Observe(time.Since(start).Seconds())
 	return nil
 }
@@ -179,6 +189,10 @@ func (s *UserService) UpdateUser(ctx context.Context, user *repository.User) err
 	s.cache.Delete(ctx, fmt.Sprintf("user:%d", user.ID))
 	s.cache.Delete(ctx, fmt.Sprintf("user:username:%s", user.Username))
 
+	if err := s.events.PublishAfterCommit(ctx, UserUpdated{User: user}); err != nil {
+		log.Printf("Failed to publish UserUpdated: %v", err)
+	}
+
 	observability.ServiceMethodDuration.WithLabelValues("UserService", "UpdateUser").Observe(time.Since(start).Seconds())
 	return nil
 }
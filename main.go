@@ -11,36 +11,35 @@ import (
 
 	"go-spring.com/internal/config"
 	"go-spring.com/internal/container"
+	"go-spring.com/internal/observability"
 	"go-spring.com/internal/server"
 )
 
 func main() {
-	vaultConfig := &config.VaultConfig{
-		Address:    os.Getenv("VAULT_ADDR"),
-		Token:      os.Getenv("VAULT_TOKEN"),
-		MountPath:  os.Getenv("VAULT_MOUNT_PATH"),
-		SecretPath: os.Getenv("VAULT_SECRET_PATH"),
-	}
-
-	// Load configuration from Vault
-	cfg, err := config.LoadFromVault(vaultConfig)
+	// Load configuration from defaults, config file, environment and Vault
+	loader := config.NewDefaultLoader()
+	cfg, err := loader.Load()
 	if err != nil {
-		log.Fatalf("Failed to load configuration from Vault: %v", err)
+		log.Fatalf("Failed to load configuration: %v", err)
 	}
 
 	// Initialize dependency injection container
-	container, err := container.NewContainer()
+	container, err := container.NewContainer(cfg, container.WithLoader(loader))
 	if err != nil {
 		log.Fatalf("Failed to initialize container: %v", err)
 	}
-	defer container.Close()
+
+	// Start background components such as the config watcher
+	if err := container.Start(context.Background()); err != nil {
+		log.Fatalf("Failed to start container: %v", err)
+	}
 
 	// Create and configure HTTP server
 	srv := server.NewServer(container)
 
 	// Start server in a goroutine
 	go func() {
-		log.Printf("Starting server on port %d", cfg.Server.Port)
+		log.Printf("Starting server on %s, management on %s", srv.Addr(), srv.ManagementAddr())
 		if err := srv.Start(); err != nil && err != http.ErrServerClosed {
 			log.Fatalf("Failed to start server: %v", err)
 		}
@@ -58,7 +57,20 @@ func main() {
 	defer cancel()
 
 	if err := srv.Shutdown(ctx); err != nil {
-		log.Fatalf("Server forced to shutdown: %v", err)
+		log.Printf("Server forced to shutdown: %v", err)
+	}
+
+	// Stop components in reverse dependency order once requests are drained
+	stopCtx, stopCancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer stopCancel()
+
+	if err := container.Stop(stopCtx); err != nil {
+		log.Printf("Failed to stop components: %v", err)
+	}
+
+	// The tracer provider is shared by every container, so flush it last
+	if err := observability.ShutdownTracerProvider(stopCtx); err != nil {
+		log.Printf("Failed to shut down tracer provider: %v", err)
 	}
 
 	log.Println("Server exiting")